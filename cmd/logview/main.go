@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+	"github.com/CoreumFoundation/coreum-tools/pkg/run"
+)
+
+func main() {
+	run.Tool("logview", func(ctx context.Context) error {
+		flags := logger.Flags(logger.ToolDefaultConfig, "logview")
+		format := flags.StringP("output", "o", string(logger.FormatYAML), "Format of printed logs: yaml | console | json | logfmt | gcp | ecs")
+		color := flags.String("color", string(logger.ColorAuto), "Colors printed logs: auto | always | never")
+		level := flags.String("level", "", "Prints entries at this level or above")
+		loggers := flags.StringArray("logger", nil, "Prints entries of this logger and its children, might be repeated")
+		since := flags.String("since", "", "Prints entries logged at this time or later, RFC 3339 time or duration before now, e.g. 15m")
+		until := flags.String("until", "", "Prints entries logged before this time, RFC 3339 time or duration before now, e.g. 15m")
+		fields := flags.StringArray("field", nil, "Prints entries having the field of the value, e.g. module=parallel, might be repeated")
+		flags.Usage = func() {
+			fmt.Fprintf(os.Stderr, "Pretty-prints JSON logs read from files or standard input.\n\n"+
+				"Usage: logview [flags] [file...]\n\nFlags:\n%s", flags.FlagUsages())
+		}
+		if err := flags.Parse(os.Args[1:]); err != nil {
+			return err
+		}
+
+		f, err := newFilter(*level, *loggers, *since, *until, *fields)
+		if err != nil {
+			return err
+		}
+		r, err := newRenderer(logger.Format(*format), logger.ColorMode(*color).Enabled(logger.OutputStdout))
+		if err != nil {
+			return err
+		}
+
+		out := bufio.NewWriter(os.Stdout)
+		defer out.Flush()
+
+		files := flags.Args()
+		if len(files) == 0 {
+			return view(ctx, os.Stdin, out, f, r)
+		}
+		for _, file := range files {
+			if err := viewFile(ctx, file, out, f, r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func viewFile(ctx context.Context, path string, out io.Writer, f filter, r *renderer) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+
+	return view(ctx, file, out, f, r)
+}
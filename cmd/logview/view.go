@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+)
+
+const maxLineSize = 16 * 1024 * 1024
+
+// view prints entries read from the input, lines which are not JSON objects are printed unchanged
+func view(ctx context.Context, in io.Reader, out io.Writer, f filter, r *renderer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return errors.WithStack(err)
+		}
+
+		line := scanner.Bytes()
+		e, err := parseEntry(line)
+		if err != nil {
+			if !f.empty() {
+				continue
+			}
+			if _, err := out.Write(append(line, '\n')); err != nil {
+				return errors.WithStack(err)
+			}
+			continue
+		}
+		if !f.match(e) {
+			continue
+		}
+		if err := r.render(out, e, line); err != nil {
+			return err
+		}
+	}
+	return errors.WithStack(scanner.Err())
+}
+
+// entry is the log entry parsed from JSON line
+type entry struct {
+	zapcore.Entry
+	fields []zapcore.Field
+	values map[string]interface{}
+}
+
+func parseEntry(line []byte) (entry, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		return entry{}, errors.New("line is not a JSON object")
+	}
+
+	e := entry{values: map[string]interface{}{}}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return entry{}, errors.WithStack(err)
+		}
+		key := token.(string)
+		var value interface{}
+		if err := dec.Decode(&value); err != nil {
+			return entry{}, errors.WithStack(err)
+		}
+		e.values[key] = value
+
+		str, _ := value.(string)
+		switch key {
+		case logger.EncoderConfig.TimeKey:
+			e.Time = parseTime(value)
+		case logger.EncoderConfig.LevelKey:
+			if err := e.Level.UnmarshalText([]byte(str)); err != nil {
+				return entry{}, errors.Wrapf(err, "invalid level %q", str)
+			}
+		case logger.EncoderConfig.NameKey:
+			e.LoggerName = str
+		case logger.EncoderConfig.MessageKey:
+			e.Message = str
+		case logger.EncoderConfig.StacktraceKey:
+			e.Stack = str
+		case logger.EncoderConfig.CallerKey:
+			e.Caller = parseCaller(str)
+		default:
+			e.fields = append(e.fields, field(key, value))
+		}
+	}
+	if _, ok := e.values[logger.EncoderConfig.MessageKey]; !ok {
+		return entry{}, errors.New("line is not a log entry")
+	}
+	return e, nil
+}
+
+func parseTime(value interface{}) time.Time {
+	switch value := value.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return time.Time{}
+		}
+		return t
+	case json.Number:
+		// epoch time in seconds
+		seconds, err := value.Float64()
+		if err != nil {
+			return time.Time{}
+		}
+		return time.Unix(0, int64(seconds*float64(time.Second)))
+	default:
+		return time.Time{}
+	}
+}
+
+func parseCaller(value string) zapcore.EntryCaller {
+	i := strings.LastIndexByte(value, ':')
+	if i < 0 {
+		return zapcore.NewEntryCaller(0, value, 0, value != "")
+	}
+	line, err := strconv.Atoi(value[i+1:])
+	if err != nil {
+		return zapcore.NewEntryCaller(0, value, 0, true)
+	}
+	return zapcore.NewEntryCaller(0, value[:i], line, true)
+}
+
+func field(key string, value interface{}) zapcore.Field {
+	switch value := value.(type) {
+	case string:
+		return zap.String(key, value)
+	case bool:
+		return zap.Bool(key, value)
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return zap.Int64(key, i)
+		}
+		if f, err := value.Float64(); err == nil {
+			return zap.Float64(key, f)
+		}
+		return zap.String(key, value.String())
+	default:
+		return zap.Reflect(key, value)
+	}
+}
+
+// filter selects entries to print
+type filter struct {
+	level   *zapcore.Level
+	loggers []string
+	since   time.Time
+	until   time.Time
+	fields  map[string]string
+}
+
+func newFilter(level string, loggers []string, since, until string, fields []string) (filter, error) {
+	f := filter{
+		loggers: loggers,
+		fields:  map[string]string{},
+	}
+	if level != "" {
+		l, err := zapcore.ParseLevel(level)
+		if err != nil {
+			return filter{}, errors.WithStack(err)
+		}
+		f.level = &l
+	}
+
+	var err error
+	now := time.Now()
+	if f.since, err = parseTimeFlag(since, now); err != nil {
+		return filter{}, err
+	}
+	if f.until, err = parseTimeFlag(until, now); err != nil {
+		return filter{}, err
+	}
+
+	for _, item := range fields {
+		key, value, ok := strings.Cut(item, "=")
+		if !ok || key == "" {
+			return filter{}, errors.Errorf("invalid field filter %q, expected key=value", item)
+		}
+		f.fields[key] = value
+	}
+	return f, nil
+}
+
+func parseTimeFlag(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, errors.Errorf("invalid time %q, expected RFC 3339 time or duration", value)
+	}
+	return t, nil
+}
+
+func (f filter) empty() bool {
+	return f.level == nil && len(f.loggers) == 0 && f.since.IsZero() && f.until.IsZero() && len(f.fields) == 0
+}
+
+func (f filter) match(e entry) bool {
+	if f.level != nil && e.Level < *f.level {
+		return false
+	}
+	if !f.since.IsZero() && (e.Time.IsZero() || e.Time.Before(f.since)) {
+		return false
+	}
+	if !f.until.IsZero() && (e.Time.IsZero() || !e.Time.Before(f.until)) {
+		return false
+	}
+	if len(f.loggers) > 0 && !matchLogger(e.LoggerName, f.loggers) {
+		return false
+	}
+	for key, expected := range f.fields {
+		value, ok := lookup(e.values, key)
+		if !ok || fmt.Sprint(value) != expected {
+			return false
+		}
+	}
+	return true
+}
+
+func matchLogger(name string, loggers []string) bool {
+	for _, l := range loggers {
+		if name == l || strings.HasPrefix(name, l+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns value of the field, keys of nested objects are separated by dots
+func lookup(values map[string]interface{}, key string) (interface{}, bool) {
+	if value, ok := values[key]; ok {
+		return value, true
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] != '.' {
+			continue
+		}
+		nested, ok := values[key[:i]].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if value, ok := lookup(nested, key[i+1:]); ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// renderer prints entries in the requested format
+type renderer struct {
+	format  logger.Format
+	encoder zapcore.Encoder
+}
+
+func newRenderer(format logger.Format, color bool) (*renderer, error) {
+	encoder, err := logger.NewEncoder(format, logger.EncoderConfig, color)
+	if err != nil {
+		return nil, err
+	}
+	return &renderer{
+		format:  format,
+		encoder: encoder,
+	}, nil
+}
+
+func (r *renderer) render(out io.Writer, e entry, line []byte) error {
+	if r.format == logger.FormatJSON {
+		_, err := out.Write(append(line, '\n'))
+		return errors.WithStack(err)
+	}
+
+	buf, err := r.encoder.EncodeEntry(e.Entry, e.fields)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer buf.Free()
+
+	_, err = out.Write(buf.Bytes())
+	return errors.WithStack(err)
+}
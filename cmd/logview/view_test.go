@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+)
+
+const input = `{"level":"debug","ts":"2023-01-02T03:04:00Z","logger":"app","msg":"Starting"}
+not a log entry
+{"level":"info","ts":"2023-01-02T03:05:00Z","logger":"app.parallel","caller":"parallel/group.go:10","msg":"Task started","task":{"name":"fetch"},"attempt":2}
+{"level":"error","ts":"2023-01-02T03:06:00Z","logger":"app.parallel","msg":"Task failed","task":{"name":"store"}}
+{"level":"warn","ts":"2023-01-02T03:07:00Z","logger":"application","msg":"Slow"}
+`
+
+func TestViewFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    string
+		loggers  []string
+		since    string
+		until    string
+		fields   []string
+		messages []string
+	}{
+		{name: "all", messages: []string{"Starting", "not a log entry", "Task started", "Task failed", "Slow"}},
+		{name: "level", level: "info", messages: []string{"Task started", "Task failed", "Slow"}},
+		{name: "logger", loggers: []string{"app.parallel"}, messages: []string{"Task started", "Task failed"}},
+		{name: "parent logger", loggers: []string{"app"}, messages: []string{"Starting", "Task started", "Task failed"}},
+		{
+			name:     "time range",
+			since:    "2023-01-02T03:05:00Z",
+			until:    "2023-01-02T03:07:00Z",
+			messages: []string{"Task started", "Task failed"},
+		},
+		{name: "nested field", fields: []string{"task.name=store"}, messages: []string{"Task failed"}},
+		{name: "number field", fields: []string{"attempt=2", "logger=app.parallel"}, messages: []string{"Task started"}},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := newFilter(tc.level, tc.loggers, tc.since, tc.until, tc.fields)
+			require.NoError(t, err)
+			r, err := newRenderer(logger.FormatJSON, false)
+			require.NoError(t, err)
+
+			out := &bytes.Buffer{}
+			require.NoError(t, view(context.Background(), strings.NewReader(input), out, f, r))
+
+			messages := []string{}
+			for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+				e, err := parseEntry([]byte(line))
+				if err != nil {
+					messages = append(messages, line)
+					continue
+				}
+				messages = append(messages, e.Message)
+			}
+			require.Equal(t, tc.messages, messages)
+		})
+	}
+}
+
+func TestViewYAML(t *testing.T) {
+	f, err := newFilter("error", nil, "", "", nil)
+	require.NoError(t, err)
+	r, err := newRenderer(logger.FormatYAML, false)
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, view(context.Background(), strings.NewReader(input), out, f, r))
+	require.Equal(t, "- log: \"2023-01-02 03:06:00.000 ERROR Task failed\"\n"+
+		"  details:\n"+
+		"    task: \n"+
+		"      name: \"store\"\n"+
+		"    logged:\n"+
+		"      by: \"app.parallel\"\n"+
+		"      at: \":0\"\n", out.String())
+}
+
+func TestInvalidFilters(t *testing.T) {
+	_, err := newFilter("loud", nil, "", "", nil)
+	require.Error(t, err)
+	_, err = newFilter("", nil, "yesterday", "", nil)
+	require.Error(t, err)
+	_, err = newFilter("", nil, "", "", []string{"task"})
+	require.Error(t, err)
+}
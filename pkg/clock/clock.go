@@ -0,0 +1,102 @@
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+type clockFieldType int
+
+const clockField clockFieldType = iota
+
+var realClock = Real()
+
+// Clock is the source of time used by time-dependent code, so it might be
+// replaced in tests.
+type Clock interface {
+	// Now returns current time
+	Now() time.Time
+
+	// NewTimer creates a new timer which sends the current time on its channel
+	// after at least duration d
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer represents a single event, like time.Timer.
+type Timer interface {
+	// C returns the channel on which the time is delivered
+	C() <-chan time.Time
+
+	// Stop prevents the timer from firing, returns false if the timer has
+	// already expired or been stopped
+	Stop() bool
+
+	// Reset changes the timer to expire after duration d, returns true if the
+	// timer had been active
+	Reset(d time.Duration) bool
+}
+
+// Real returns the clock backed by the time package.
+func Real() Clock {
+	return systemClock{}
+}
+
+// WithClock adds clock to context
+func WithClock(ctx context.Context, clock Clock) context.Context {
+	return context.WithValue(ctx, clockField, clock)
+}
+
+// Get gets clock from context, real clock is returned if context contains none
+func Get(ctx context.Context) Clock {
+	clock, ok := ctx.Value(clockField).(Clock)
+	if !ok {
+		return realClock
+	}
+	return clock
+}
+
+// Sleep waits for duration d using the clock from context. It returns ctx.Err()
+// if context is closed earlier.
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := Get(ctx).NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C():
+		return nil
+	}
+}
+
+// SleepUntil waits until time t using the clock from context. It returns
+// ctx.Err() if context is closed earlier.
+func SleepUntil(ctx context.Context, t time.Time) error {
+	return Sleep(ctx, t.Sub(Get(ctx).Now()))
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{timer: time.NewTimer(d)}
+}
+
+type systemTimer struct {
+	timer *time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t systemTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+func (t systemTimer) Reset(d time.Duration) bool {
+	return t.timer.Reset(d)
+}
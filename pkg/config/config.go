@@ -0,0 +1,451 @@
+// Package config loads configuration of the application into a tagged struct
+// from command line flags, environment variables and YAML or JSON file.
+//
+// Each exported field of the struct is bound to the flag named after the field
+// in kebab case, e.g. ListenAddress to --listen-address. Fields of nested
+// structs are prefixed with the name of the struct field, e.g. --db-host, while
+// fields of embedded structs are not. Field might be configured using tags:
+//
+//	type Config struct {
+//	    ListenAddress string        `config:"listen" default:":8080" usage:"Address to listen on"`
+//	    Timeout       time.Duration `default:"5s" usage:"Request timeout"`
+//	    Peers         []string      `short:"p" required:"true" usage:"Addresses of peers"`
+//	    Token         string        `env:"API_TOKEN" usage:"Token of the API"`
+//	    Internal      string        `config:"-"`
+//	    DB            struct {
+//	        Host string `default:"localhost"`
+//	    }
+//	}
+//
+// Values are taken from, in the order of precedence: flags, environment
+// variables named after flags, e.g. APP_DB_HOST for --db-host and prefix APP,
+// file passed in --config flag or <PREFIX>_CONFIG variable and the defaults,
+// which are the values set in the struct before loading unless overridden by
+// the default tag. In the file, nested structs are objects:
+//
+//	listen: ":9090"
+//	peers: ["peer1:26656", "peer2:26656"]
+//	db:
+//	  host: db.local
+//
+// Once loaded, fields tagged as required must be set and structs implementing
+// Validator are validated.
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+)
+
+// FileFlag is the flag passing the path of the config file
+const FileFlag = "config"
+
+// Validator is implemented by config structs validating themselves once loaded
+type Validator interface {
+	Validate() error
+}
+
+// Option is the option of Load
+type Option func(o *options)
+
+type options struct {
+	name      string
+	envPrefix string
+	lookupEnv func(key string) (string, bool)
+	output    io.Writer
+	flags     []func(flags *pflag.FlagSet)
+}
+
+// WithName is the option which sets the name of the application printed in
+// the help. Default is the name of the executable.
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithEnvPrefix is the option which sets the prefix of environment variables,
+// e.g. APP for APP_DB_HOST
+func WithEnvPrefix(prefix string) Option {
+	return func(o *options) {
+		o.envPrefix = prefix
+	}
+}
+
+// WithLookupEnv is the option which sets the function reading environment
+// variables. Default is os.LookupEnv.
+func WithLookupEnv(lookupEnv func(key string) (string, bool)) Option {
+	return func(o *options) {
+		o.lookupEnv = lookupEnv
+	}
+}
+
+// WithOutput is the option which sets the writer the help and errors are
+// printed to. Default is stderr.
+func WithOutput(output io.Writer) Option {
+	return func(o *options) {
+		o.output = output
+	}
+}
+
+// WithFlags is the option adding flags defined outside of the config struct,
+// so they are accepted and printed in the help, e.g. the ones of logger.AddFlags.
+// Once Load returns, values of the flags, including the path of the config file
+// in FileFlag, might be read from the flag set passed to addFlags.
+func WithFlags(addFlags func(flags *pflag.FlagSet)) Option {
+	return func(o *options) {
+		o.flags = append(o.flags, addFlags)
+	}
+}
+
+// EnvPrefix returns the prefix of environment variables derived from the
+// name of the application, e.g. MY_APP for my-app
+func EnvPrefix(name string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, name))
+}
+
+type field struct {
+	path       []string
+	flag       string
+	short      string
+	env        string
+	usage      string
+	required   bool
+	defaultTag *string
+	value      *fieldValue
+}
+
+// Load fills the struct pointed by config with values taken from args, which
+// don't contain the name of the executable, environment variables and the
+// config file. pflag.ErrHelp is returned if args contain --help.
+func Load(config interface{}, args []string, opts ...Option) error {
+	o := options{
+		name:      filepath.Base(os.Args[0]),
+		lookupEnv: os.LookupEnv,
+		output:    os.Stderr,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	v := reflect.ValueOf(config)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return errors.Errorf("config must be a pointer to struct, got %T", config)
+	}
+
+	c := &collector{envPrefix: o.envPrefix}
+	if err := c.collect(v.Elem(), nil); err != nil {
+		return err
+	}
+
+	flags := pflag.NewFlagSet(o.name, pflag.ContinueOnError)
+	flags.SetOutput(o.output)
+	flags.SortFlags = false
+	flags.String(FileFlag, "", "YAML or JSON config file (env "+envName(o.envPrefix, FileFlag)+")")
+	for _, f := range c.fields {
+		if f.defaultTag != nil {
+			if err := f.value.Set(*f.defaultTag); err != nil {
+				return errors.Wrapf(err, "invalid default value of %s", f.flag)
+			}
+			f.value.changed = false
+		}
+		// pflag panics if flag is defined twice
+		if flags.Lookup(f.flag) != nil {
+			return errors.Errorf("flag --%s of field %s is defined twice", f.flag, strings.Join(f.path, "."))
+		}
+		if f.short != "" && flags.ShorthandLookup(f.short) != nil {
+			return errors.Errorf("shorthand -%s of field %s is used by flag --%s",
+				f.short, strings.Join(f.path, "."), flags.ShorthandLookup(f.short).Name)
+		}
+		flag := flags.VarPF(f.value, f.flag, f.short, f.help())
+		if f.value.value.Kind() == reflect.Bool {
+			flag.NoOptDefVal = "true"
+		}
+	}
+	for _, addFlags := range o.flags {
+		addFlags(flags)
+	}
+	flags.Usage = func() {
+		fmt.Fprintf(o.output, "Usage of %s:\n%s", o.name, flags.FlagUsages())
+	}
+	if err := flags.Parse(args); err != nil {
+		return errors.WithStack(err)
+	}
+
+	path, err := flags.GetString(FileFlag)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !flags.Changed(FileFlag) {
+		// path is stored in the flag, so it is available to the callers of WithFlags
+		if path, _ = o.lookupEnv(envName(o.envPrefix, FileFlag)); path != "" {
+			if err := flags.Set(FileFlag, path); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	var file map[string]interface{}
+	if path != "" {
+		if file, err = readFile(path); err != nil {
+			return err
+		}
+		if err := c.checkKeys(file, nil); err != nil {
+			return errors.Wrapf(err, "invalid config file %s", path)
+		}
+	}
+
+	for _, f := range c.fields {
+		if flags.Changed(f.flag) {
+			continue
+		}
+		if f.env != "" {
+			if value, ok := o.lookupEnv(f.env); ok {
+				if err := f.value.Set(value); err != nil {
+					return errors.Wrapf(err, "invalid value of %s environment variable", f.env)
+				}
+				continue
+			}
+		}
+		if value, ok := lookup(file, f.path); ok {
+			if err := f.setFileValue(value); err != nil {
+				return errors.Wrapf(err, "invalid value of %s in config file %s", strings.Join(f.path, "."), path)
+			}
+		}
+	}
+
+	var missing []string
+	for _, f := range c.fields {
+		if f.required && !f.value.changed {
+			missing = append(missing, "--"+f.flag)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("required config is not set: %s", strings.Join(missing, ", "))
+	}
+	// nested structs are validated before the ones containing them
+	for i := len(c.validators) - 1; i >= 0; i-- {
+		if err := c.validators[i].Validate(); err != nil {
+			return errors.Wrap(err, "invalid config")
+		}
+	}
+	return nil
+}
+
+func (f *field) help() string {
+	usage := f.usage
+	if f.env != "" {
+		usage += " (env " + f.env + ")"
+	}
+	if f.required {
+		usage += " (required)"
+	}
+	return strings.TrimSpace(usage)
+}
+
+// setFileValue sets the value taken from the config file, list items are set
+// one by one so they might contain commas
+func (f *field) setFileValue(value interface{}) error {
+	isList := f.value.value.Kind() == reflect.Slice && !f.value.isText()
+	items, ok := value.([]interface{})
+	switch {
+	case value == nil:
+		return nil
+	case ok && !isList:
+		return errors.New("unexpected list")
+	case !ok:
+		if _, ok := value.(map[string]interface{}); ok {
+			return errors.New("unexpected object")
+		}
+		return f.value.Set(fmt.Sprint(value))
+	}
+
+	slice := reflect.MakeSlice(f.value.value.Type(), 0, len(items))
+	for _, item := range items {
+		elem := reflect.New(slice.Type().Elem()).Elem()
+		if err := parse(elem, fmt.Sprint(item)); err != nil {
+			return err
+		}
+		slice = reflect.Append(slice, elem)
+	}
+	f.value.value.Set(slice)
+	f.value.changed = true
+	return nil
+}
+
+type collector struct {
+	envPrefix  string
+	fields     []*field
+	nodes      map[string]bool
+	validators []Validator
+}
+
+func (c *collector) collect(v reflect.Value, path []string) error {
+	if validator, ok := v.Addr().Interface().(Validator); ok {
+		c.validators = append(c.validators, validator)
+	}
+	if c.nodes == nil {
+		c.nodes = map[string]bool{}
+	}
+	c.nodes[strings.Join(path, ".")] = true
+
+	t := v.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		name := sf.Tag.Get("config")
+		if !sf.IsExported() || name == "-" {
+			continue
+		}
+		fv := v.Field(i)
+
+		if sf.Type.Kind() == reflect.Struct && !supported(sf.Type) {
+			if sf.Anonymous && name == "" {
+				if err := c.collect(fv, path); err != nil {
+					return err
+				}
+				continue
+			}
+			if name == "" {
+				name = kebabCase(sf.Name)
+			}
+			if err := c.collect(fv, append(path[:len(path):len(path)], name)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !supported(sf.Type) {
+			return errors.Errorf("unsupported type %s of config field %s", sf.Type, sf.Name)
+		}
+		if name == "" {
+			name = kebabCase(sf.Name)
+		}
+		f := &field{
+			path:     append(path[:len(path):len(path)], name),
+			short:    sf.Tag.Get("short"),
+			usage:    sf.Tag.Get("usage"),
+			required: sf.Tag.Get("required") == "true",
+			value:    &fieldValue{value: fv},
+		}
+		f.flag = strings.Join(f.path, "-")
+		switch env, ok := sf.Tag.Lookup("env"); {
+		case !ok:
+			f.env = envName(c.envPrefix, f.flag)
+		case env != "-":
+			f.env = env
+		}
+		if def, ok := sf.Tag.Lookup("default"); ok {
+			f.defaultTag = &def
+		}
+		c.fields = append(c.fields, f)
+	}
+	return nil
+}
+
+// checkKeys returns error if the config file contains keys not matching any field
+func (c *collector) checkKeys(values map[string]interface{}, path []string) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		keyPath := append(path[:len(path):len(path)], key)
+		if c.nodes[strings.Join(keyPath, ".")] {
+			nested, ok := values[key].(map[string]interface{})
+			if !ok {
+				return errors.Errorf("%s must be an object", strings.Join(keyPath, "."))
+			}
+			if err := c.checkKeys(nested, keyPath); err != nil {
+				return err
+			}
+			continue
+		}
+		if c.hasField(keyPath) {
+			continue
+		}
+		// logger section is read by the logger, see logger.Sources
+		if len(path) == 0 && key == logger.ConfigSection {
+			continue
+		}
+		return errors.Errorf("unknown key %s", strings.Join(keyPath, "."))
+	}
+	return nil
+}
+
+func (c *collector) hasField(path []string) bool {
+	for _, f := range c.fields {
+		if strings.Join(f.path, ".") == strings.Join(path, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+func readFile(path string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(content, &values); err != nil {
+		return nil, errors.Wrapf(err, "parsing config file %s failed", path)
+	}
+	return values, nil
+}
+
+func lookup(values map[string]interface{}, path []string) (interface{}, bool) {
+	var value interface{} = values
+	for _, key := range path {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func envName(prefix, flag string) string {
+	name := strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+	if prefix == "" {
+		return name
+	}
+	return prefix + "_" + name
+}
+
+// kebabCase converts field name to kebab case, e.g. DBHost to db-host
+func kebabCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				b.WriteByte('-')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
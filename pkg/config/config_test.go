@@ -0,0 +1,251 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+type dbConfig struct {
+	Host string `default:"localhost" usage:"Database host"`
+	Port int    `default:"5432"`
+}
+
+func (c dbConfig) Validate() error {
+	if c.Port <= 0 {
+		return errors.Errorf("invalid port %d", c.Port)
+	}
+	return nil
+}
+
+type Common struct {
+	Name string `usage:"Name of the instance"`
+}
+
+type testConfig struct {
+	Common
+
+	ListenAddress string        `config:"listen" default:":8080" usage:"Address to listen on"`
+	Timeout       time.Duration `default:"5s" usage:"Request timeout"`
+	Verbose       bool          `short:"v"`
+	Peers         []string      `short:"p" usage:"Addresses of peers"`
+	Ports         []uint16
+	Level         zapcore.Level `default:"info"`
+	Token         string        `env:"API_TOKEN"`
+	Ratio         float64
+	Internal      string `config:"-"`
+	DB            dbConfig
+	unexported    string
+}
+
+func testEnv(env map[string]string) Option {
+	return WithLookupEnv(func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	})
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg := testConfig{Ratio: 0.5, Peers: []string{"default:26656"}}
+	require.NoError(t, Load(&cfg, nil, testEnv(nil)))
+	require.Equal(t, testConfig{
+		ListenAddress: ":8080",
+		Timeout:       5 * time.Second,
+		Peers:         []string{"default:26656"},
+		Level:         zapcore.InfoLevel,
+		Ratio:         0.5,
+		DB:            dbConfig{Host: "localhost", Port: 5432},
+	}, cfg)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+name: file
+listen: ":9090"
+timeout: 1m
+peers: ["peer1:26656", "peer,2:26656"]
+ports: [1, 2]
+level: warn
+ratio: 0.25
+db:
+  host: db.local
+  port: 6543
+log:
+  format: json
+`)
+
+	// file only
+	cfg := testConfig{}
+	require.NoError(t, Load(&cfg, []string{"--config", path}, testEnv(nil)))
+	require.Equal(t, testConfig{
+		Common:        Common{Name: "file"},
+		ListenAddress: ":9090",
+		Timeout:       time.Minute,
+		Peers:         []string{"peer1:26656", "peer,2:26656"},
+		Ports:         []uint16{1, 2},
+		Level:         zapcore.WarnLevel,
+		Ratio:         0.25,
+		DB:            dbConfig{Host: "db.local", Port: 6543},
+	}, cfg)
+
+	// env overrides file
+	cfg = testConfig{}
+	require.NoError(t, Load(&cfg, nil, WithEnvPrefix("APP"), testEnv(map[string]string{
+		"APP_CONFIG":  path,
+		"APP_LISTEN":  ":7070",
+		"APP_PEERS":   "peer3:26656,peer4:26656",
+		"APP_DB_HOST": "db.env",
+		"API_TOKEN":   "token",
+		"TOKEN":       "ignored",
+	})))
+	require.Equal(t, ":7070", cfg.ListenAddress)
+	require.Equal(t, []string{"peer3:26656", "peer4:26656"}, cfg.Peers)
+	require.Equal(t, "db.env", cfg.DB.Host)
+	require.Equal(t, 6543, cfg.DB.Port)
+	require.Equal(t, "token", cfg.Token)
+
+	// flags override env
+	cfg = testConfig{}
+	require.NoError(t, Load(&cfg, []string{"--listen=:6060", "-v", "-p", "peer5:26656", "-p", "peer6:26656", "--db-port", "1"},
+		WithEnvPrefix("APP"), testEnv(map[string]string{
+			"APP_CONFIG": path,
+			"APP_LISTEN": ":7070",
+			"APP_PEERS":  "peer3:26656",
+		})))
+	require.Equal(t, ":6060", cfg.ListenAddress)
+	require.True(t, cfg.Verbose)
+	require.Equal(t, []string{"peer5:26656", "peer6:26656"}, cfg.Peers)
+	require.Equal(t, 1, cfg.DB.Port)
+	require.Equal(t, time.Minute, cfg.Timeout)
+}
+
+func TestLoadJSONFile(t *testing.T) {
+	path := writeFile(t, "config.json", `{"listen": ":9090", "db": {"port": 1234}}`)
+
+	cfg := testConfig{}
+	require.NoError(t, Load(&cfg, []string{"--config=" + path}, testEnv(nil)))
+	require.Equal(t, ":9090", cfg.ListenAddress)
+	require.Equal(t, 1234, cfg.DB.Port)
+	require.Equal(t, "localhost", cfg.DB.Host)
+}
+
+func TestLoadValidation(t *testing.T) {
+	type requiredConfig struct {
+		Peers []string `required:"true"`
+		Chain string   `required:"true"`
+		DB    dbConfig
+	}
+
+	cfg := requiredConfig{}
+	err := Load(&cfg, []string{"--chain", "coreum"}, testEnv(nil))
+	require.EqualError(t, err, "required config is not set: --peers")
+
+	// zero values set explicitly satisfy required fields
+	type requiredZeroConfig struct {
+		Enabled bool     `required:"true"`
+		Port    int      `required:"true"`
+		Name    string   `required:"true"`
+		Peers   []string `required:"true"`
+	}
+	zeroCfg := requiredZeroConfig{}
+	path := writeFile(t, "zero.yaml", "peers: []\n")
+	require.NoError(t, Load(&zeroCfg, []string{"--enabled=false", "--port", "0", "--config", path},
+		testEnv(map[string]string{"NAME": ""})))
+	err = Load(&requiredZeroConfig{}, nil, testEnv(nil))
+	require.EqualError(t, err, "required config is not set: --enabled, --port, --name, --peers")
+
+	cfg = requiredConfig{}
+	err = Load(&cfg, []string{"--chain", "coreum", "--peers", "a", "--db-port", "-1"}, testEnv(nil))
+	require.EqualError(t, err, "invalid config: invalid port -1")
+
+	for name, content := range map[string]string{
+		"unknown": "listen: ':8080'\nlisten-address: ':8080'\n",
+		"nested":  "db:\n  hostname: localhost\n",
+		"object":  "db: localhost\n",
+		"type":    "timeout: soon\n",
+		"list":    "listen: [a, b]\n",
+		"syntax":  "listen: [",
+	} {
+		path := writeFile(t, name+".yaml", content)
+		require.Error(t, Load(&testConfig{}, []string{"--config", path}, testEnv(nil)), name)
+	}
+
+	require.Error(t, Load(&testConfig{}, []string{"--timeout", "soon"}, testEnv(nil)))
+	require.Error(t, Load(&testConfig{}, []string{"--unknown"}, testEnv(nil), WithOutput(&bytes.Buffer{})))
+	require.Error(t, Load(&testConfig{}, nil, testEnv(map[string]string{"RATIO": "high"})))
+	require.Error(t, Load(testConfig{}, nil, testEnv(nil)))
+	require.Error(t, Load(&struct{ Field *string }{}, nil, testEnv(nil)))
+}
+
+func TestLoadFlagConflicts(t *testing.T) {
+	type Embedded struct {
+		Listen string
+	}
+	type embeddedConfig struct {
+		Embedded
+		Listen string
+	}
+	err := Load(&embeddedConfig{}, nil, testEnv(nil))
+	require.EqualError(t, err, "flag --listen of field listen is defined twice")
+
+	type shortConfig struct {
+		Peers   []string `short:"p"`
+		Profile string   `short:"p"`
+	}
+	err = Load(&shortConfig{}, nil, testEnv(nil))
+	require.EqualError(t, err, "shorthand -p of field profile is used by flag --peers")
+
+	type fileConfig struct {
+		Config string
+	}
+	require.Error(t, Load(&fileConfig{}, nil, testEnv(nil)))
+}
+
+func TestLoadHelp(t *testing.T) {
+	output := &bytes.Buffer{}
+	err := Load(&testConfig{}, []string{"--help"}, WithName("app"), WithEnvPrefix("APP"), WithOutput(output),
+		testEnv(nil), WithFlags(func(flags *pflag.FlagSet) {
+			flags.String("log-format", "console", "Format of log output")
+		}))
+	require.ErrorIs(t, err, pflag.ErrHelp)
+
+	help := output.String()
+	require.Contains(t, help, "Usage of app:\n")
+	require.Contains(t, help, "--config string")
+	require.Contains(t, help, "(env APP_CONFIG)")
+	require.Contains(t, help, `--listen string`)
+	require.Contains(t, help, `Address to listen on (env APP_LISTEN) (default ":8080")`)
+	require.Contains(t, help, "--timeout duration")
+	require.Contains(t, help, "-p, --peers strings")
+	require.Contains(t, help, "--db-host string")
+	require.Contains(t, help, "(env API_TOKEN)")
+	require.Contains(t, help, "--log-format string")
+	require.NotContains(t, help, "internal")
+}
+
+func TestKebabCase(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Host":          "host",
+		"ListenAddress": "listen-address",
+		"DBHost":        "db-host",
+		"HTTPPort":      "http-port",
+		"GRPCAddr2":     "grpc-addr2",
+		"ChainID":       "chain-id",
+	} {
+		require.Equal(t, expected, kebabCase(name), name)
+	}
+	require.Equal(t, "MY_APP", EnvPrefix("my-app"))
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"encoding"
+	"encoding/csv"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+var (
+	_ pflag.Value = &fieldValue{}
+
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// fieldValue is the flag value stored in the struct field
+type fieldValue struct {
+	value reflect.Value
+
+	// changed is set once the value is set, so the next values are appended to slices
+	changed bool
+}
+
+// supported returns true if values of the type might be parsed
+func supported(t reflect.Type) bool {
+	if t == durationType || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Slice && supported(t.Elem())
+	default:
+		return false
+	}
+}
+
+// Set parses the value and stores it in the field. Comma-separated values are
+// appended to slices, replacing the default ones on the first call.
+func (v *fieldValue) Set(value string) error {
+	if v.value.Kind() != reflect.Slice || v.isText() {
+		v.changed = true
+		return parse(v.value, value)
+	}
+
+	var items []string
+	if value != "" {
+		var err error
+		items, err = csv.NewReader(strings.NewReader(value)).Read()
+		if err != nil {
+			return errors.Wrapf(err, "parsing list %q failed", value)
+		}
+	}
+	slice := v.value
+	if !v.changed {
+		slice = reflect.MakeSlice(v.value.Type(), 0, len(items))
+	}
+	for _, item := range items {
+		elem := reflect.New(v.value.Type().Elem()).Elem()
+		if err := parse(elem, strings.TrimSpace(item)); err != nil {
+			return err
+		}
+		slice = reflect.Append(slice, elem)
+	}
+	v.value.Set(slice)
+	v.changed = true
+	return nil
+}
+
+func (v *fieldValue) isText() bool {
+	return reflect.PointerTo(v.value.Type()).Implements(textUnmarshalerType)
+}
+
+// String returns the value in the form accepted by Set, zero values are empty,
+// so they are not printed in the help as defaults
+func (v *fieldValue) String() string {
+	if !v.value.IsValid() || v.value.IsZero() {
+		return ""
+	}
+	if v.value.Kind() == reflect.Slice && !v.isText() {
+		items := make([]string, 0, v.value.Len())
+		for i := range v.value.Len() {
+			items = append(items, format(v.value.Index(i)))
+		}
+		return "[" + strings.Join(items, ",") + "]"
+	}
+	return format(v.value)
+}
+
+// Type returns the name of the value type printed in the help
+func (v *fieldValue) Type() string {
+	t := v.value.Type()
+	switch {
+	case t == durationType:
+		return "duration"
+	case t.Kind() == reflect.Slice && !v.isText():
+		return elemTypeName(t.Elem()) + "s"
+	default:
+		return elemTypeName(t)
+	}
+}
+
+func elemTypeName(t reflect.Type) string {
+	switch {
+	case t == durationType:
+		return "duration"
+	case t.Kind() == reflect.String || reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return "string"
+	default:
+		return t.Kind().String()
+	}
+}
+
+func parse(v reflect.Value, value string) error {
+	if v.CanAddr() {
+		if unmarshaler, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return errors.WithStack(unmarshaler.UnmarshalText([]byte(value)))
+		}
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 0, v.Type().Bits())
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 0, v.Type().Bits())
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		v.SetFloat(f)
+	default:
+		return errors.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func format(v reflect.Value) string {
+	if marshaler, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+		if err != nil {
+			return fmt.Sprint(v.Interface())
+		}
+		return string(text)
+	}
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	return fmt.Sprint(v.Interface())
+}
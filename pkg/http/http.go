@@ -11,6 +11,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/CoreumFoundation/coreum-tools/pkg/retry"
+	"github.com/CoreumFoundation/coreum-tools/pkg/trace"
 )
 
 // RetryableClientConfig is the config for the RetryableClient.
@@ -45,15 +46,29 @@ func NewRetryableClient(cfg RetryableClientConfig) RetryableClient {
 func (c RetryableClient) DoJSON(ctx context.Context, method, url string, reqBody interface{}, resDecoder func([]byte) error) error {
 	doCtx, doCtxCancel := context.WithTimeout(ctx, c.cfg.DoTimeout)
 	defer doCtxCancel()
-	return retry.Do(doCtx, c.cfg.RetryDelay, func() error {
-		reqCtx, reqCtxCancel := context.WithTimeout(ctx, c.cfg.RequestTimeout)
+	return retry.DoContext(doCtx, c.cfg.RetryDelay, func(attemptCtx context.Context) error {
+		// request is bounded by RequestTimeout only, like before, but it is
+		// a child of the span of the attempt
+		reqCtx := ctx
+		if sc, ok := trace.Get(attemptCtx); ok {
+			reqCtx = trace.WithSpanContext(reqCtx, sc)
+		}
+		reqCtx, reqCtxCancel := context.WithTimeout(reqCtx, c.cfg.RequestTimeout)
 		defer reqCtxCancel()
 
 		return doJSON(reqCtx, method, url, reqBody, resDecoder)
 	})
 }
 
-func doJSON(ctx context.Context, method, url string, reqBody interface{}, resDecoder func([]byte) error) error {
+func doJSON(ctx context.Context, method, url string, reqBody interface{}, resDecoder func([]byte) error) (retErr error) {
+	// each attempt is a separate span of the trace continued by the server
+	ctx, span := trace.StartChild(ctx, "HTTP "+method)
+	span.SetAttribute("http.method", method)
+	span.SetAttribute("http.url", url)
+	defer func() {
+		span.End(retErr)
+	}()
+
 	reqBodyBytes, err := json.Marshal(reqBody)
 	if err != nil {
 		return errors.Errorf("failed to marshal request body, err: %v", err)
@@ -67,6 +82,7 @@ func doJSON(ctx context.Context, method, url string, reqBody interface{}, resDec
 	// fix for the EOF error
 	req.Close = true
 	req.Header.Set("Content-Type", "application/json")
+	trace.Inject(ctx, req.Header)
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
@@ -74,6 +90,7 @@ func doJSON(ctx context.Context, method, url string, reqBody interface{}, resDec
 	}
 
 	defer resp.Body.Close()
+	span.SetAttribute("http.status_code", resp.StatusCode)
 	bodyData, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return errors.Errorf("failed to read the response body, err: %v", err)
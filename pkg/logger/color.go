@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"os"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// ColorMode defines when logs are colored
+type ColorMode string
+
+const (
+	// ColorAuto colors logs written to the standard output or error if it is a terminal
+	// and NO_COLOR environment variable is not set
+	ColorAuto ColorMode = "auto"
+
+	// ColorAlways colors logs written to the standard output or error
+	ColorAlways ColorMode = "always"
+
+	// ColorNever turns off coloring
+	ColorNever ColorMode = "never"
+)
+
+var validColorModes = map[ColorMode]bool{
+	ColorAuto:   true,
+	ColorAlways: true,
+	ColorNever:  true,
+}
+
+const (
+	colorReset   = "\x1b[0m"
+	colorBold    = "\x1b[1m"
+	colorDim     = "\x1b[2m"
+	colorRed     = "\x1b[31m"
+	colorYellow  = "\x1b[33m"
+	colorBlue    = "\x1b[34m"
+	colorMagenta = "\x1b[35m"
+)
+
+// Enabled returns true if logs written to the output, OutputStderr or
+// OutputStdout, should be colored. Files are never colored.
+func (m ColorMode) Enabled(path string) bool {
+	var file *os.File
+	switch path {
+	case OutputStderr:
+		file = os.Stderr
+	case OutputStdout:
+		file = os.Stdout
+	default:
+		return false
+	}
+
+	switch m {
+	case ColorAlways:
+		return true
+	case ColorAuto:
+		return os.Getenv("NO_COLOR") == "" && isTerminal(file)
+	default:
+		return false
+	}
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func levelColor(level zapcore.Level) string {
+	switch level {
+	case zapcore.DebugLevel:
+		return colorMagenta
+	case zapcore.InfoLevel:
+		return colorBlue
+	case zapcore.WarnLevel:
+		return colorYellow
+	default:
+		return colorRed
+	}
+}
+
+// newColorConsoleEncoder returns zap console encoder coloring levels, dimming
+// caller and stack and highlighting messages of errors. Caller is always printed
+// in the short form.
+func newColorConsoleEncoder(config zapcore.EncoderConfig) zapcore.Encoder {
+	config.EncodeLevel = zapcore.LowercaseColorLevelEncoder
+	config.EncodeCaller = func(caller zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
+		enc.AppendString(colorDim + caller.TrimmedPath() + colorReset)
+	}
+	return &colorConsoleEncoder{Encoder: zapcore.NewConsoleEncoder(config)}
+}
+
+type colorConsoleEncoder struct {
+	zapcore.Encoder
+}
+
+func (e *colorConsoleEncoder) Clone() zapcore.Encoder {
+	return &colorConsoleEncoder{Encoder: e.Encoder.Clone()}
+}
+
+func (e *colorConsoleEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	if entry.Level >= zapcore.ErrorLevel && entry.Message != "" {
+		entry.Message = colorBold + colorRed + entry.Message + colorReset
+	}
+	if entry.Stack != "" {
+		entry.Stack = colorDim + entry.Stack + colorReset
+	}
+	return e.Encoder.EncodeEntry(entry, fields)
+}
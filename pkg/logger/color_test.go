@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+func TestColorModeEnabled(t *testing.T) {
+	require.True(t, ColorAlways.Enabled(OutputStderr))
+	require.True(t, ColorAlways.Enabled(OutputStdout))
+	require.False(t, ColorAlways.Enabled("app.log"))
+	require.False(t, ColorNever.Enabled(OutputStderr))
+	require.False(t, ColorMode("").Enabled(OutputStderr))
+
+	t.Setenv("NO_COLOR", "1")
+	require.False(t, ColorAuto.Enabled(OutputStderr))
+}
+
+func TestColorEncoders(t *testing.T) {
+	entry := zapcore.Entry{
+		Level:   zapcore.ErrorLevel,
+		Message: "Failed",
+		Caller:  zapcore.NewEntryCaller(0, "/src/logger/color_test.go", 10, true),
+		Stack:   "stack",
+	}
+	fields := []zapcore.Field{zap.Error(errors.New("error message")), zap.String("field", "value")}
+
+	for _, format := range []Format{FormatConsole, FormatYAML} {
+		plainEncoder, err := NewEncoder(format, EncoderConfig, false)
+		require.NoError(t, err)
+		colorEncoder, err := NewEncoder(format, EncoderConfig, true)
+		require.NoError(t, err)
+
+		plain, err := plainEncoder.EncodeEntry(entry, fields)
+		require.NoError(t, err)
+		colored, err := colorEncoder.EncodeEntry(entry, fields)
+		require.NoError(t, err)
+
+		require.NotContains(t, plain.String(), "\x1b[", format)
+		require.Contains(t, colored.String(), colorRed, format)
+		require.Contains(t, colored.String(), colorDim, format)
+		// coloring doesn't change the content
+		require.Equal(t, plain.String(), ansiEscape.ReplaceAllString(colored.String(), ""), format)
+	}
+}
+
+func TestColorYAMLStructure(t *testing.T) {
+	entry := zapcore.Entry{
+		Level:      zapcore.WarnLevel,
+		LoggerName: "app",
+		Message:    "Failed",
+		Caller:     zapcore.NewEntryCaller(0, "/src/logger/color_test.go", 10, true),
+		Stack:      "frame1\nframe2\n",
+	}
+	fields := []zapcore.Field{zap.Error(errors.New("first line\nsecond line")), zap.String("field", "value")}
+
+	plainEncoder, err := NewEncoder(FormatYAML, EncoderConfig, false)
+	require.NoError(t, err)
+	colorEncoder, err := NewEncoder(FormatYAML, EncoderConfig, true)
+	require.NoError(t, err)
+	plain, err := plainEncoder.EncodeEntry(entry, fields)
+	require.NoError(t, err)
+	colored, err := colorEncoder.EncodeEntry(entry, fields)
+	require.NoError(t, err)
+
+	// escapes are replaced by the printable marker, if they were written
+	// outside of scalars, the structure of the document would change
+	const marker = "\u00a7"
+	var plainDoc, coloredDoc interface{}
+	require.NoError(t, yaml.Unmarshal(plain.Bytes(), &plainDoc))
+	require.NoError(t, yaml.Unmarshal([]byte(ansiEscape.ReplaceAllString(colored.String(), marker)), &coloredDoc))
+	require.Equal(t, plainDoc, removeMarker(coloredDoc, marker))
+}
+
+func removeMarker(doc interface{}, marker string) interface{} {
+	switch doc := doc.(type) {
+	case string:
+		return strings.ReplaceAll(doc, marker, "")
+	case []interface{}:
+		for i, item := range doc {
+			doc[i] = removeMarker(item, marker)
+		}
+	case map[string]interface{}:
+		for key, value := range doc {
+			doc[key] = removeMarker(value, marker)
+		}
+	}
+	return doc
+}
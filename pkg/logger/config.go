@@ -5,6 +5,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
+	"go.uber.org/zap/zapcore"
 
 	"github.com/CoreumFoundation/coreum-tools/pkg/must"
 )
@@ -28,45 +29,180 @@ type Config struct {
 	// Format defines the format of log output
 	Format Format
 
-	// Verbose turns on verbose logging
+	// Level is the minimum level of logged messages, info by default
+	Level zapcore.Level
+
+	// Verbose turns on verbose logging, overriding Level with debug
 	Verbose bool
+
+	// LevelOverrides defines levels of the messages logged by named loggers, see Levels
+	LevelOverrides map[string]zapcore.Level
+
+	// Outputs defines destinations of logs, if empty logs are written to stderr
+	Outputs []Output
+
+	// Sampling configures sampling of repetitive log entries, it is turned off by default
+	Sampling SamplingConfig
+
+	// Color defines when logs written to the standard output or error are colored,
+	// they are not colored if empty
+	Color ColorMode
+
+	// Redact defines patterns of keys whose values are replaced by RedactedValue, see DefaultRedactPatterns.
+	// Values of Secret type and struct fields tagged with `log:"redact"` are always redacted.
+	Redact []string
 }
 
 // ToolDefaultConfig stores handy default configuration used by tools run manually by humans
 var ToolDefaultConfig = Config{
 	Format:  FormatConsole,
 	Verbose: false,
+	Color:   ColorAuto,
+	Redact:  DefaultRedactPatterns,
 }
 
 // ServiceDefaultConfig stores handy default configuration used by services
 var ServiceDefaultConfig = Config{
 	Format:  FormatJSON,
 	Verbose: true,
+	Redact:  DefaultRedactPatterns,
+}
+
+var encoders = map[Format]func(config zapcore.EncoderConfig, color bool) zapcore.Encoder{
+	FormatConsole: func(config zapcore.EncoderConfig, color bool) zapcore.Encoder {
+		if color {
+			return newColorConsoleEncoder(config)
+		}
+		return zapcore.NewConsoleEncoder(config)
+	},
+	FormatJSON: func(config zapcore.EncoderConfig, color bool) zapcore.Encoder {
+		return zapcore.NewJSONEncoder(config)
+	},
+	FormatYAML: func(config zapcore.EncoderConfig, color bool) zapcore.Encoder {
+		c := newConsoleEncoder(0)
+		c.color = color
+		return c
+	},
+	FormatLogfmt: func(config zapcore.EncoderConfig, color bool) zapcore.Encoder {
+		return newLogfmtEncoder(config)
+	},
+	FormatGCP: func(config zapcore.EncoderConfig, color bool) zapcore.Encoder {
+		return newGCPEncoder(config)
+	},
+	FormatECS: func(config zapcore.EncoderConfig, color bool) zapcore.Encoder {
+		return newECSEncoder(config)
+	},
 }
 
-var validFormats = map[Format]bool{
-	FormatConsole: true,
-	FormatJSON:    true,
-	FormatYAML:    true,
+// NewEncoder returns encoder producing logs in the format. The config is
+// ignored by the YAML encoder, keys defined by it are replaced by the ones of
+// the schema in GCP and ECS formats. Color is used only by console and YAML formats.
+func NewEncoder(format Format, config zapcore.EncoderConfig, color bool) (zapcore.Encoder, error) {
+	newEnc, ok := encoders[format]
+	if !ok {
+		return nil, errors.Errorf("incorrect logging format %s", format)
+	}
+	return newEnc(config, color), nil
 }
 
-// ConfigureWithCLI configures logger based on CLI flags
+// ConfigureWithCLI configures logger based on CLI flags, environment variables
+// and the config file. Flags take precedence over environment variables named
+// after them, e.g. LOG_FORMAT and LOG_VERBOSE, which take precedence over the
+// "log" section of YAML or JSON file passed in --log-config flag or LOG_CONFIG
+// variable. Values not set by any of them are taken from the default config.
 func ConfigureWithCLI(defaultConfig Config) Config {
+	config, err := configure(defaultConfig, os.Args[1:], os.LookupEnv)
+	if err != nil {
+		panic(err)
+	}
+	return config
+}
+
+// Sources defines where the logger settings not passed in flags are taken from
+type Sources struct {
+	// EnvPrefix is prepended to the names of environment variables, e.g. APP_LOG_FORMAT for APP
+	EnvPrefix string
+
+	// ConfigFile is the file whose "log" section is read if --log-config is not passed,
+	// e.g. the config file of the application
+	ConfigFile string
+
+	// LookupEnv reads environment variables, os.LookupEnv is used if nil
+	LookupEnv func(key string) (string, bool)
+}
+
+// ConfigureWithFlags configures logger like ConfigureWithCLI but based on the
+// flag set already parsed by the caller, e.g. by the config package, so the
+// command line is parsed once. Logger flags must be added to it by AddFlags
+// with the same default config.
+func ConfigureWithFlags(defaultConfig Config, flags *pflag.FlagSet, sources Sources) (Config, error) {
+	if sources.LookupEnv == nil {
+		sources.LookupEnv = os.LookupEnv
+	}
+	return configureFlags(defaultConfig, flags, sources)
+}
+
+func configure(defaultConfig Config, args []string, lookupEnv func(key string) (string, bool)) (Config, error) {
 	flags := pflag.NewFlagSet("logger", pflag.ContinueOnError)
 	flags.ParseErrorsWhitelist.UnknownFlags = true
 	AddFlags(defaultConfig, flags)
 	// Dummy flag to turn off printing usage of this flag set
 	flags.BoolP("help", "h", false, "")
 
-	_ = flags.Parse(os.Args[1:])
+	_ = flags.Parse(args)
+	return configureFlags(defaultConfig, flags, Sources{LookupEnv: lookupEnv})
+}
+
+func configureFlags(defaultConfig Config, flags *pflag.FlagSet, sources Sources) (Config, error) {
+	defaultConfig = foldVerbose(defaultConfig)
+	if err := applySources(flags, sources); err != nil {
+		return Config{}, err
+	}
 
 	defaultConfig.Format = Format(must.String(flags.GetString("log-format")))
+	if _, ok := encoders[defaultConfig.Format]; !ok {
+		return Config{}, errors.Errorf("incorrect logging format %s", defaultConfig.Format)
+	}
+	level, err := zapcore.ParseLevel(must.String(flags.GetString("log-level")))
+	if err != nil {
+		return Config{}, errors.WithStack(err)
+	}
+	defaultConfig.Level = level
 	defaultConfig.Verbose = must.Bool(flags.GetBool("verbose"))
-	if !validFormats[defaultConfig.Format] {
-		panic(errors.Errorf("incorrect logging format %s", defaultConfig.Format))
+	overrides, err := ParseLevelOverrides(must.String(flags.GetString("log-levels")))
+	if err != nil {
+		return Config{}, err
+	}
+	defaultConfig.LevelOverrides = overrides
+
+	defaultConfig.Sampling.Initial = must.Int(flags.GetInt("log-sampling-initial"))
+	defaultConfig.Sampling.Thereafter = must.Int(flags.GetInt("log-sampling-thereafter"))
+	samplingInterval, err := flags.GetDuration("log-sampling-interval")
+	must.OK(err)
+	defaultConfig.Sampling.Interval = samplingInterval
+
+	defaultConfig.Color = ColorMode(must.String(flags.GetString("log-color")))
+	if !validColorModes[defaultConfig.Color] {
+		return Config{}, errors.Errorf("incorrect logging color mode %s", defaultConfig.Color)
 	}
 
-	return defaultConfig
+	defaultConfig.Redact, err = flags.GetStringSlice("log-redact")
+	must.OK(err)
+
+	outputSpecs, err := flags.GetStringArray("log-output")
+	must.OK(err)
+	if len(outputSpecs) > 0 {
+		defaultConfig.Outputs = nil
+		for _, spec := range outputSpecs {
+			output, err := ParseOutput(spec)
+			if err != nil {
+				return Config{}, err
+			}
+			defaultConfig.Outputs = append(defaultConfig.Outputs, output)
+		}
+	}
+
+	return defaultConfig, nil
 }
 
 // Flags returns new flag set preconfigured with logger-specific options
@@ -76,8 +212,45 @@ func Flags(defaultConfig Config, name string) *pflag.FlagSet {
 	return flags
 }
 
+// foldVerbose turns verbose default config into the one with debug level, so
+// the level might still be set by the sources of lower precedence than flags
+func foldVerbose(config Config) Config {
+	if config.Verbose {
+		config.Level = zapcore.DebugLevel
+		config.Verbose = false
+	}
+	return config
+}
+
 // AddFlags adds flags defined by logger
 func AddFlags(defaultConfig Config, flags *pflag.FlagSet) {
-	flags.String("log-format", string(defaultConfig.Format), "Format of log output: console | json")
+	defaultConfig = foldVerbose(defaultConfig)
+	flags.String("log-format", string(defaultConfig.Format), "Format of log output: console | json | yaml | logfmt | gcp | ecs")
+	flags.String("log-config", "", "YAML or JSON file configuring logger in the \""+ConfigSection+"\" section, "+
+		"keys are the names of these flags without log- prefix")
+	flags.String("log-level", defaultConfig.Level.String(), "Minimum level of logged messages: debug | info | warn | error")
 	flags.BoolP("verbose", "v", defaultConfig.Verbose, "Turns on verbose logging")
+	flags.String("log-levels", FormatLevelOverrides(defaultConfig.LevelOverrides),
+		"Levels of named loggers, e.g. parallel=warn,app.updater=debug")
+
+	outputs := make([]string, 0, len(defaultConfig.Outputs))
+	for _, output := range defaultConfig.Outputs {
+		outputs = append(outputs, output.String())
+	}
+	flags.Int("log-sampling-initial", defaultConfig.Sampling.Initial,
+		"Number of log entries with the same message and level written per interval before sampling starts, 0 turns sampling off")
+	flags.Int("log-sampling-thereafter", defaultConfig.Sampling.Thereafter,
+		"Once sampling starts, every n-th log entry with the same message and level is written, 0 drops all of them")
+	flags.Duration("log-sampling-interval", defaultConfig.Sampling.Interval,
+		"Interval after which sampling counters are reset and the number of dropped log entries is reported")
+	color := defaultConfig.Color
+	if color == "" {
+		color = ColorNever
+	}
+	flags.String("log-color", string(color), "Colors logs written to terminal: auto | always | never")
+	flags.StringSlice("log-redact", defaultConfig.Redact,
+		"Patterns of keys whose values are redacted in logs, e.g. *password*,*mnemonic*")
+	flags.StringArray("log-output", outputs,
+		"Destination of logs, might be repeated, e.g. stderr?format=console or "+
+			"/var/log/app.log?format=json&level=info&max-size=100MB&max-age=24h&max-backups=7&compress=true")
 }
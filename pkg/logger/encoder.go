@@ -1,11 +1,18 @@
 package logger
 
 import (
+	"bytes"
+	"encoding"
 	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
@@ -37,7 +44,11 @@ type console struct {
 	array                  bool
 	skipErrorStackTrace    bool
 	containsStackTrace     bool
+	color                  bool
 	buffer                 *buffer.Buffer
+
+	// visited contains pointers being encoded, used to detect cycles
+	visited map[uintptr]bool
 }
 
 func (c *console) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
@@ -62,8 +73,7 @@ func (c *console) AddBinary(key string, value []byte) {
 
 func (c *console) AddByteString(key string, value []byte) {
 	c.addKey(key)
-	c.buffer.AppendString(string(value))
-	c.buffer.AppendByte('\n')
+	appendString(c.buffer, string(value), c.indentation())
 }
 
 func (c *console) AddBool(key string, value bool) {
@@ -197,18 +207,26 @@ func (c *console) Clone() zapcore.Encoder {
 		array:               c.array,
 		skipErrorStackTrace: c.skipErrorStackTrace,
 		containsStackTrace:  c.containsStackTrace,
+		color:               c.color,
 		buffer:              buf,
 	}
 }
 
 func (c *console) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	// color escapes are written only inside scalars, so the output stays YAML
 	buf := bufPool.Get()
 	buf.AppendString(`- log: "`)
+	if c.color {
+		buf.AppendString(levelColor(entry.Level))
+	}
 	buf.AppendTime(entry.Time.UTC(), "2006-01-02 15:04:05.000 ")
 	buf.AppendString(strings.ToUpper(entry.Level.CapitalString()))
 	buf.AppendByte(' ')
 	if entry.Message != "" {
-		buf.AppendString(entry.Message)
+		appendEscaped(buf, entry.Message)
+	}
+	if c.color {
+		buf.AppendString(colorReset)
 	}
 	buf.AppendString("\"\n  details:\n")
 
@@ -217,6 +235,7 @@ func (c *console) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buf
 	}
 
 	subEncoder := newConsoleEncoder(0)
+	subEncoder.color = c.color
 	if entry.Level == zap.InfoLevel {
 		subEncoder.skipErrorStackTrace = true
 	}
@@ -229,26 +248,33 @@ func (c *console) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buf
 
 	must.Any(buf.Write(subEncoder.buffer.Bytes()))
 
+	dim := c.colorCode(colorDim)
 	buf.AppendString("    logged:\n")
 	if entry.LoggerName != "" {
-		buf.AppendString(`      by: "`)
-		buf.AppendString(entry.LoggerName)
-		buf.AppendString("\"\n")
+		buf.AppendString(`      by: `)
+		appendColoredQuoted(buf, entry.LoggerName, dim)
+		buf.AppendByte('\n')
 	}
 
-	buf.AppendString(`      at: "`)
-	buf.AppendString(entry.Caller.File)
-	buf.AppendByte(':')
-	buf.AppendInt(int64(entry.Caller.Line))
-	buf.AppendString("\"\n")
+	buf.AppendString(`      at: `)
+	appendColoredQuoted(buf, entry.Caller.File+":"+strconv.Itoa(entry.Caller.Line), dim)
+	buf.AppendByte('\n')
 
 	if !c.containsStackTrace && !subEncoder.containsStackTrace && entry.Stack != "" {
 		buf.AppendString(`      stack: `)
-		appendString(buf, entry.Stack, "  ")
+		appendColoredString(buf, entry.Stack, "  ", dim)
 	}
 	return buf, nil
 }
 
+// colorCode returns the color escape if coloring is enabled
+func (c *console) colorCode(color string) string {
+	if !c.color {
+		return ""
+	}
+	return color
+}
+
 func (c *console) AppendBool(value bool) {
 	c.addComma()
 	c.buffer.AppendBool(value)
@@ -368,6 +394,7 @@ func (c *console) AppendTime(value time.Time) {
 func (c *console) AppendArray(marshaler zapcore.ArrayMarshaler) error {
 	subEncoder := newConsoleEncoder(c.nested + 1)
 	subEncoder.array = true
+	subEncoder.visited = c.visited
 	defer subEncoder.buffer.Free()
 
 	if err := marshaler.MarshalLogArray(subEncoder); err != nil {
@@ -381,6 +408,7 @@ func (c *console) AppendArray(marshaler zapcore.ArrayMarshaler) error {
 
 func (c *console) AppendObject(marshaler zapcore.ObjectMarshaler) error {
 	subEncoder := newConsoleEncoder(c.nested + 1)
+	subEncoder.visited = c.visited
 	if c.array {
 		subEncoder.nested--
 		subEncoder.ignoreFirstIndentation = true
@@ -398,6 +426,50 @@ func (c *console) AppendObject(marshaler zapcore.ObjectMarshaler) error {
 
 func (c *console) AppendReflected(value interface{}) error {
 	v := reflect.ValueOf(value)
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Map) && !v.IsNil() {
+		if c.visited[v.Pointer()] {
+			c.AppendString("<cycle>")
+			return nil
+		}
+		if c.visited == nil {
+			c.visited = map[uintptr]bool{}
+		}
+		c.visited[v.Pointer()] = true
+		defer delete(c.visited, v.Pointer())
+	}
+
+	switch value := value.(type) {
+	case Secret:
+		c.AppendString(RedactedValue)
+		return nil
+	case time.Time:
+		c.AppendTime(value)
+		return nil
+	case json.Number:
+		c.addComma()
+		c.buffer.AppendString(value.String())
+		c.buffer.AppendByte('\n')
+		return nil
+	case json.Marshaler:
+		if v.Kind() != reflect.Ptr || !v.IsNil() {
+			return c.appendJSON(value)
+		}
+	case encoding.TextMarshaler:
+		if v.Kind() != reflect.Ptr || !v.IsNil() {
+			text, err := value.MarshalText()
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			c.AppendString(string(text))
+			return nil
+		}
+	case fmt.Stringer:
+		if v.Kind() != reflect.Ptr || !v.IsNil() {
+			c.AppendString(value.String())
+			return nil
+		}
+	}
+
 	switch v.Kind() {
 	case reflect.Invalid:
 		c.appendNil()
@@ -439,6 +511,13 @@ func (c *console) AppendReflected(value interface{}) error {
 		return c.appendReflectedStruct(v)
 	case reflect.String:
 		c.AppendString(v.String())
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		// there is no meaningful way to serialize these kinds, so only the type is printed
+		if v.IsNil() {
+			c.appendNil()
+		} else {
+			c.AppendString("<" + v.Type().String() + ">")
+		}
 	default:
 		return errors.Errorf("unable to serialize %s", v.Kind())
 	}
@@ -465,7 +544,7 @@ func (c *console) addKey(key string) {
 		c.buffer.AppendString(c.indentation())
 		c.buffer.AppendString("    ")
 	}
-	c.buffer.AppendString(key)
+	appendKey(c.buffer, key)
 	c.buffer.AppendString(": ")
 	c.element++
 }
@@ -482,21 +561,19 @@ func (c *console) appendError(field zapcore.Field) bool {
 		err := field.Interface.(error)
 		c.buffer.AppendString(ind)
 		c.buffer.AppendString("      msg: ")
-		c.buffer.AppendByte('"')
-		c.buffer.AppendString(err.Error())
-		c.buffer.AppendString("\"\n")
+		appendColoredString(c.buffer, err.Error(), c.indentation()+"  ", c.colorCode(colorBold+colorRed))
 
 		if !c.skipErrorStackTrace {
 			errStack, ok := err.(stackTracer)
 			if ok {
 				stack := errStack.StackTrace()
 				if len(stack) > 0 {
+					c.buffer.AppendString(c.indentation())
 					c.buffer.AppendString("      stack:")
 					for _, frame := range stack {
 						c.buffer.AppendString(ind)
-						c.buffer.AppendString("      - \"")
-						c.buffer.AppendString(string(must.Bytes(frame.MarshalText())))
-						c.buffer.AppendByte('"')
+						c.buffer.AppendString("      - ")
+						appendColoredQuoted(c.buffer, string(must.Bytes(frame.MarshalText())), c.colorCode(colorDim))
 					}
 					c.buffer.AppendByte('\n')
 					c.containsStackTrace = true
@@ -533,9 +610,8 @@ func (c *console) appendReflectedSequence(v reflect.Value) error {
 
 func (c *console) appendReflectedMapping(v reflect.Value) error {
 	return c.AppendObject(zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
-		iter := v.MapRange()
-		for iter.Next() {
-			if err := enc.AddReflected(iter.Key().String(), iter.Value().Interface()); err != nil {
+		for _, key := range sortedMapKeys(v) {
+			if err := enc.AddReflected(key.name, v.MapIndex(key.value).Interface()); err != nil {
 				return err
 			}
 		}
@@ -543,16 +619,114 @@ func (c *console) appendReflectedMapping(v reflect.Value) error {
 	}))
 }
 
+type mapKey struct {
+	value reflect.Value
+	name  string
+
+	// elem is the key, or the value of the interface key, whose type orders keys
+	// of different types, it is invalid for nil interface keys
+	elem     reflect.Value
+	typeName string
+}
+
+// sortedMapKeys returns keys of the map formatted and sorted. Keys are grouped
+// by type, nil interface keys first, numeric keys are sorted by value, the
+// other ones by formatted name.
+func sortedMapKeys(v reflect.Value) []mapKey {
+	keys := make([]mapKey, 0, v.Len())
+	for _, key := range v.MapKeys() {
+		k := mapKey{value: key, name: formatMapKey(key), elem: key}
+		if key.Kind() == reflect.Interface {
+			k.elem = key.Elem()
+		}
+		if k.elem.IsValid() {
+			k.typeName = k.elem.Type().String()
+		}
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].less(keys[j])
+	})
+	return keys
+}
+
+func (k mapKey) less(other mapKey) bool {
+	if k.elem.IsValid() != other.elem.IsValid() {
+		return !k.elem.IsValid()
+	}
+	if k.typeName != other.typeName {
+		return k.typeName < other.typeName
+	}
+	if k.elem.IsValid() {
+		a, b := k.elem, other.elem
+		switch a.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if a.Int() != b.Int() {
+				return a.Int() < b.Int()
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if a.Uint() != b.Uint() {
+				return a.Uint() < b.Uint()
+			}
+		case reflect.Float32, reflect.Float64:
+			// NaN keys go after the numbers
+			aNaN, bNaN := math.IsNaN(a.Float()), math.IsNaN(b.Float())
+			if aNaN != bNaN {
+				return bNaN
+			}
+			if !aNaN && a.Float() != b.Float() {
+				return a.Float() < b.Float()
+			}
+		}
+	}
+	return k.name < other.name
+}
+
+func formatMapKey(key reflect.Value) string {
+	if key.Kind() == reflect.Interface {
+		if key.IsNil() {
+			return "null"
+		}
+		key = key.Elem()
+	}
+	if marshaler, ok := key.Interface().(encoding.TextMarshaler); ok {
+		if key.Kind() != reflect.Ptr || !key.IsNil() {
+			if text, err := marshaler.MarshalText(); err == nil {
+				return string(text)
+			}
+		}
+	}
+
+	switch key.Kind() {
+	case reflect.String:
+		return key.String()
+	case reflect.Bool:
+		return strconv.FormatBool(key.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(key.Float(), 'g', -1, key.Type().Bits())
+	case reflect.Complex64, reflect.Complex128:
+		return strconv.FormatComplex(key.Complex(), 'g', -1, key.Type().Bits())
+	default:
+		return fmt.Sprint(key.Interface())
+	}
+}
+
 func (c *console) appendReflectedStruct(v reflect.Value) error {
 	return c.AppendObject(zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
-		t := v.Type()
-		n := t.NumField()
-		for i := 0; i < n; i++ {
-			f := t.Field(i)
-			if !f.IsExported() {
+		for _, f := range structFields(v.Type()) {
+			fv, ok := fieldByIndex(v, f.index)
+			if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+				continue
+			}
+			if f.redact {
+				enc.AddString(f.name, RedactedValue)
 				continue
 			}
-			if err := enc.AddReflected(f.Name, v.FieldByIndex(f.Index).Interface()); err != nil {
+			if err := enc.AddReflected(f.name, fv.Interface()); err != nil {
 				return err
 			}
 		}
@@ -560,18 +734,196 @@ func (c *console) appendReflectedStruct(v reflect.Value) error {
 	}))
 }
 
+// appendJSON appends value of the type implementing json.Marshaler
+func (c *console) appendJSON(value json.Marshaler) error {
+	data, err := value.MarshalJSON()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return errors.WithStack(err)
+	}
+	return c.AppendReflected(decoded)
+}
+
+// appendString appends YAML scalar. Multiline strings are written as literal
+// block scalars if possible, the other ones are double-quoted.
 func appendString(buffer *buffer.Buffer, value string, indentation string) {
-	if strings.Contains(value, "\n") {
-		buffer.AppendString("\n")
-		buffer.AppendString(indentation)
-		buffer.AppendString("      \"")
-		buffer.AppendString(strings.ReplaceAll(value, "\n", "\n       "+indentation))
-	} else {
-		buffer.AppendByte('"')
-		buffer.AppendString(value)
+	appendColoredString(buffer, value, indentation, "")
+}
+
+// appendColoredString appends value like appendString, color escapes, if any,
+// are written inside the scalar, around each line of literal block scalar
+func appendColoredString(buffer *buffer.Buffer, value string, indentation string, color string) {
+	if !strings.Contains(value, "\n") || !literalSafe(value) {
+		appendColoredQuoted(buffer, value, color)
+		buffer.AppendByte('\n')
+		return
+	}
+
+	body := strings.TrimRight(value, "\n")
+	switch len(value) - len(body) {
+	case 0:
+		buffer.AppendString("|-\n")
+	case 1:
+		buffer.AppendString("|\n")
+	default:
+		buffer.AppendString("|+\n")
+	}
+	for _, line := range strings.Split(body, "\n") {
+		if line != "" {
+			buffer.AppendString(indentation)
+			buffer.AppendString("      ")
+			buffer.AppendString(color)
+			buffer.AppendString(line)
+			if color != "" {
+				buffer.AppendString(colorReset)
+			}
+		}
+		buffer.AppendByte('\n')
+	}
+	for i := len(value) - len(body); i > 1; i-- {
+		buffer.AppendByte('\n')
+	}
+}
+
+// literalSafe returns true if the value might be written as literal block
+// scalar without changing its content
+func literalSafe(value string) bool {
+	body := strings.TrimLeft(value, "\n")
+	if body == "" || body[0] == ' ' {
+		// indentation of the block would be detected incorrectly
+		return false
+	}
+	for _, line := range strings.Split(value, "\n") {
+		if strings.HasPrefix(line, "\t") {
+			return false
+		}
+	}
+	for _, r := range value {
+		if r != '\n' && r != '\t' && (!isPrintable(r) || r == utf8.RuneError || r == '\ufeff') {
+			return false
+		}
+	}
+	return utf8.ValidString(value)
+}
+
+// appendQuoted appends double-quoted YAML scalar
+func appendQuoted(buffer *buffer.Buffer, value string) {
+	appendColoredQuoted(buffer, value, "")
+}
+
+// appendColoredQuoted appends value like appendQuoted, with color escapes, if
+// any, inside the quotes
+func appendColoredQuoted(buffer *buffer.Buffer, value string, color string) {
+	buffer.AppendByte('"')
+	buffer.AppendString(color)
+	appendEscaped(buffer, value)
+	if color != "" {
+		buffer.AppendString(colorReset)
 	}
 	buffer.AppendByte('"')
-	buffer.AppendByte('\n')
+}
+
+// appendEscaped appends value escaped according to the rules of double-quoted YAML scalars
+func appendEscaped(buffer *buffer.Buffer, value string) {
+	for i := 0; i < len(value); {
+		r, size := utf8.DecodeRuneInString(value[i:])
+		if r == utf8.RuneError && size == 1 {
+			// invalid UTF-8 can't be represented in YAML
+			buffer.AppendString(`\ufffd`)
+			i++
+			continue
+		}
+		i += size
+
+		switch r {
+		case '"':
+			buffer.AppendString(`\"`)
+		case '\\':
+			buffer.AppendString(`\\`)
+		case '\x00':
+			buffer.AppendString(`\0`)
+		case '\a':
+			buffer.AppendString(`\a`)
+		case '\b':
+			buffer.AppendString(`\b`)
+		case '\t':
+			buffer.AppendString(`\t`)
+		case '\n':
+			buffer.AppendString(`\n`)
+		case '\v':
+			buffer.AppendString(`\v`)
+		case '\f':
+			buffer.AppendString(`\f`)
+		case '\r':
+			buffer.AppendString(`\r`)
+		case '\x1b':
+			buffer.AppendString(`\e`)
+		case '\u0085':
+			buffer.AppendString(`\N`)
+		case '\u2028':
+			buffer.AppendString(`\L`)
+		case '\u2029':
+			buffer.AppendString(`\P`)
+		default:
+			switch {
+			case r == '\ufeff' || !isPrintable(r):
+				if r <= 0xff {
+					buffer.AppendString(fmt.Sprintf(`\x%02x`, r))
+				} else {
+					buffer.AppendString(fmt.Sprintf(`\u%04x`, r))
+				}
+			default:
+				buffer.AppendString(value[i-size : i])
+			}
+		}
+	}
+}
+
+// isPrintable returns true if character belongs to the printable set of YAML
+func isPrintable(r rune) bool {
+	return r == '\t' || r == '\n' || r == '\r' ||
+		(r >= 0x20 && r <= 0x7e) ||
+		r == 0x85 ||
+		(r >= 0xa0 && r <= 0xd7ff) ||
+		(r >= 0xe000 && r <= 0xfffd) ||
+		(r >= 0x10000 && r <= 0x10ffff)
+}
+
+// appendKey appends mapping key, quoting it if it can't be written as plain scalar
+func appendKey(buffer *buffer.Buffer, key string) {
+	if plainKeySafe(key) {
+		buffer.AppendString(key)
+		return
+	}
+	appendQuoted(buffer, key)
+}
+
+func plainKeySafe(key string) bool {
+	if key == "" || key[0] == ' ' || key[len(key)-1] == ' ' || key[len(key)-1] == ':' {
+		return false
+	}
+	switch key[0] {
+	case '-', '?', ':':
+		if len(key) == 1 || key[1] == ' ' {
+			return false
+		}
+	case ',', '[', ']', '{', '}', '#', '&', '*', '!', '|', '>', '\'', '"', '%', '@', '`':
+		return false
+	}
+	if strings.Contains(key, ": ") || strings.Contains(key, " #") {
+		return false
+	}
+	for _, r := range key {
+		if r == '\t' || r == '\n' || r == '\r' || r == '\ufeff' || r == utf8.RuneError || !isPrintable(r) {
+			return false
+		}
+	}
+	return true
 }
 
 type stackTracer interface {
@@ -0,0 +1,273 @@
+package logger
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"flag"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+type taggedBase struct {
+	ID      int    `json:"id"`
+	Comment string `json:"comment"`
+}
+
+type taggedConfig struct {
+	taggedBase
+	*Pointer
+	Name       string            `json:"name"`
+	Address    string            `yaml:"address" json:"addr"`
+	Label      string            `log:"label" yaml:"lbl"`
+	Comment    string            `json:"comment,omitempty"`
+	Empty      string            `json:"empty,omitempty"`
+	Labels     map[string]string `json:",omitempty"`
+	Skipped    string            `json:"-"`
+	Password   string            `log:"redact" json:"password"`
+	IP         net.IP            `json:"ip"`
+	Timeout    time.Duration     `json:"timeout"`
+	Created    time.Time         `json:"created"`
+	Raw        rawJSON           `json:"raw"`
+	unexported string
+}
+
+type Pointer struct {
+	Value string `json:"value"`
+}
+
+type rawJSON struct{}
+
+func (rawJSON) MarshalJSON() ([]byte, error) {
+	return []byte(`{"count":12,"items":["a"]}`), nil
+}
+
+type node struct {
+	Name string `json:"name"`
+	Next *node  `json:"next"`
+}
+
+func TestYAMLStructTags(t *testing.T) {
+	out := encodeYAML(t, zap.Any("config", taggedConfig{
+		taggedBase: taggedBase{ID: 7, Comment: "embedded"},
+		Name:       "service",
+		Address:    "localhost",
+		Label:      "label",
+		Comment:    "outer",
+		Skipped:    "skipped",
+		Password:   "password",
+		IP:         net.IPv4(127, 0, 0, 1),
+		Timeout:    time.Second,
+		Created:    time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+		unexported: "unexported",
+	}))
+
+	require.Equal(t, `    config: 
+      id: 7
+      name: "service"
+      address: "localhost"
+      label: "label"
+      comment: "outer"
+      password: "***"
+      ip: "127.0.0.1"
+      timeout: "1s"
+      created: 2023-01-02 03:04:05.000
+      raw: 
+        count: 12
+        items: 
+        - "a"
+`, out)
+}
+
+func TestYAMLCycle(t *testing.T) {
+	n := &node{Name: "first"}
+	n.Next = &node{Name: "second", Next: n}
+
+	out := encodeYAML(t, zap.Any("node", n))
+	require.Equal(t, `    node: 
+      name: "first"
+      next: 
+        name: "second"
+        next: "<cycle>"
+`, out)
+
+	// the same pointer used twice without cycle is encoded twice
+	shared := &node{Name: "shared"}
+	out = encodeYAML(t, zap.Any("nodes", []*node{shared, shared}))
+	require.Equal(t, 2, strings.Count(out, `name: "shared"`))
+}
+
+func TestYAMLJSONNumber(t *testing.T) {
+	require.Equal(t, "    number: 12.5\n", encodeYAML(t, zap.Reflect("number", json.Number("12.5"))))
+}
+
+var update = flag.Bool("update", false, "update golden files")
+
+type color int
+
+func (c color) MarshalText() ([]byte, error) {
+	return []byte([]string{"red", "green", "blue"}[c]), nil
+}
+
+type point struct {
+	X, Y int
+}
+
+func TestYAMLGolden(t *testing.T) {
+	entry := zapcore.Entry{
+		Level:      zapcore.InfoLevel,
+		Time:       time.Date(2023, 1, 2, 3, 4, 5, 6000000, time.UTC),
+		LoggerName: "golden",
+		Message:    "Golden entry",
+		Caller:     zapcore.NewEntryCaller(0, "logger/encoder_test.go", 10, true),
+	}
+	fields := []zapcore.Field{
+		zap.Any("strings", map[string]int{"b": 2, "a": 1, "c": 3, "aa": 11}),
+		zap.Any("ints", map[int]string{10: "ten", -1: "minus one", 2: "two", 0: "zero"}),
+		zap.Any("uints", map[uint8]bool{200: true, 3: false, 20: true}),
+		zap.Any("floats", map[float64]string{2.5: "two and half", -0.5: "minus half", 10: "ten"}),
+		zap.Any("bools", map[bool]int{true: 1, false: 0}),
+		zap.Any("mixed", map[interface{}]string{"b": "string", 1: "int", 2.5: "float", true: "bool", nil: "nil"}),
+		zap.Any("structs", map[point]string{{X: 1, Y: 2}: "first", {X: 0, Y: 5}: "second"}),
+		zap.Any("marshalers", map[color]int{2: 2, 0: 0, 1: 1}),
+		zap.Any("nested", map[string]map[int]string{"y": {2: "b", 1: "a"}, "x": {}}),
+		zap.Reflect("channel", make(chan int)),
+		zap.Reflect("nilChannel", (chan int)(nil)),
+		zap.Reflect("function", func(int) error { return nil }),
+		zap.Any("unsupported", struct {
+			Callback func()
+			Events   <-chan string
+		}{Callback: func() {}}),
+	}
+
+	encode := func() string {
+		buf, err := newConsoleEncoder(0).EncodeEntry(entry, fields)
+		require.NoError(t, err)
+		defer buf.Free()
+		return buf.String()
+	}
+
+	out := encode()
+	for i := 0; i < 20; i++ {
+		require.Equal(t, out, encode())
+	}
+
+	path := filepath.Join("testdata", "yaml.golden")
+	if *update {
+		require.NoError(t, os.MkdirAll("testdata", 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(out), 0o644))
+	}
+	golden, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, string(golden), out)
+}
+
+func TestSortedMapKeysMixed(t *testing.T) {
+	m := map[interface{}]int{nil: 0, "b": 1, "a": 2, 10: 3, 2: 4, "10": 5, int64(2): 6, math.NaN(): 7, 1.5: 8}
+	for i := 0; i < 50; i++ {
+		keys := sortedMapKeys(reflect.ValueOf(m))
+		names := make([]string, 0, len(keys))
+		types := make([]string, 0, len(keys))
+		for _, key := range keys {
+			names = append(names, key.name)
+			types = append(types, key.typeName)
+		}
+		require.Equal(t, []string{"null", "1.5", "NaN", "2", "10", "2", "10", "a", "b"}, names)
+		require.Equal(t, []string{"", "float64", "float64", "int", "int", "int64", "string", "string", "string"}, types)
+	}
+}
+
+func TestYAMLRoundTrip(t *testing.T) {
+	values := []string{
+		"",
+		"plain",
+		`with "quotes" and \backslashes\`,
+		"control \x00\x01\a\b\x1b\x7f characters",
+		"tab\tand carriage\rreturn",
+		"unicode ĄŻŚ 🚀 \u2028 \u0085 \ufeff",
+		"invalid \xff utf8",
+		"multi\nline",
+		"multi\nline\nwith trailing newline\n",
+		"multi\nline\nwith trailing newlines\n\n\n",
+		"\nleading newline",
+		"  leading spaces\nin multiline",
+		"\tleading tab\nin multiline",
+		"multiline\n\twith tab\n  and spaces  \n",
+		"multiline\nwith \"quotes\" and \x01 control",
+		"key: value # comment",
+		"- item",
+		"[flow]",
+		"'single quoted'",
+		" surrounding spaces ",
+	}
+
+	fields := []zapcore.Field{}
+	expected := map[string]interface{}{}
+	for i, value := range values {
+		key := fmt.Sprintf("value %d: %s", i, value)
+		fields = append(fields, zap.String(key, value))
+		expected[strings.ToValidUTF8(key, "\ufffd")] = strings.ToValidUTF8(value, "\ufffd")
+	}
+	fields = append(fields,
+		zap.Strings("array", values),
+		zap.Error(stderrors.New("error \"message\"\nwith second line")),
+		zap.ByteString("bytes", []byte("byte\nstring")),
+	)
+
+	message := "message with \"quotes\", \\backslash and\nnewline"
+	buf, err := newConsoleEncoder(0).EncodeEntry(zapcore.Entry{
+		Level:      zapcore.InfoLevel,
+		Time:       time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+		LoggerName: `logger "name"`,
+		Message:    message,
+		Caller:     zapcore.NewEntryCaller(0, `dir "with quotes"/file.go`, 10, true),
+	}, fields)
+	require.NoError(t, err)
+	defer buf.Free()
+
+	var decoded []struct {
+		Log     string                 `yaml:"log"`
+		Details map[string]interface{} `yaml:"details"`
+	}
+	require.NoError(t, yaml.Unmarshal(buf.Bytes(), &decoded), buf.String())
+	require.Len(t, decoded, 1)
+
+	require.Equal(t, "2023-01-02 03:04:05.000 INFO "+message, decoded[0].Log)
+	details := decoded[0].Details
+	for key, value := range expected {
+		require.Equal(t, value, details[key], key)
+	}
+	array := []interface{}{}
+	for _, value := range values {
+		array = append(array, strings.ToValidUTF8(value, "\ufffd"))
+	}
+	require.Equal(t, array, details["array"])
+	require.Equal(t, map[string]interface{}{"msg": "error \"message\"\nwith second line"}, details["error"])
+	require.Equal(t, "byte\nstring", details["bytes"])
+	require.Equal(t, map[string]interface{}{
+		"by": `logger "name"`,
+		"at": `dir "with quotes"/file.go:10`,
+	}, details["logged"])
+}
+
+func encodeYAML(t *testing.T, fields ...zapcore.Field) string {
+	buf, err := newConsoleEncoder(0).EncodeEntry(zapcore.Entry{}, fields)
+	require.NoError(t, err)
+	defer buf.Free()
+
+	// only details are returned, without the header and the "logged" section
+	out := buf.String()
+	out = out[strings.Index(out, "  details:\n")+len("  details:\n"):]
+	return out[:strings.Index(out, "    logged:\n")]
+}
@@ -0,0 +1,5 @@
+package logger
+
+// NewSamplingCore is exported for tests using the fake clock of paralleltest,
+// which can't be imported by the tests of this package
+var NewSamplingCore = newSamplingCore
@@ -0,0 +1,181 @@
+package logger
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// structField describes a field of a struct encoded by the YAML encoder
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	redact    bool
+}
+
+var structFieldsCache sync.Map
+
+// structFields returns the encoded fields of the struct type. Names are taken from
+// the log, yaml and json tags, in this order, fields tagged with "-" are skipped
+// and fields of embedded structs are promoted, following the rules of encoding/json.
+func structFields(t reflect.Type) []structField {
+	if fields, ok := structFieldsCache.Load(t); ok {
+		return fields.([]structField)
+	}
+
+	var fields []structField
+	depths := map[string]int{}
+	positions := map[string]int{}
+	addStructFields(t, nil, 0, map[reflect.Type]bool{}, func(field structField, depth int, tagged bool) {
+		// shallower field wins, on the same depth the tagged one wins, otherwise both are dropped
+		pos, exists := positions[field.name]
+		if !exists {
+			positions[field.name] = len(fields)
+			depths[field.name] = depth*2 + boolToInt(!tagged)
+			fields = append(fields, field)
+			return
+		}
+		rank := depth*2 + boolToInt(!tagged)
+		switch {
+		case rank < depths[field.name]:
+			fields[pos] = field
+			depths[field.name] = rank
+		case rank == depths[field.name]:
+			fields[pos].name = ""
+		}
+	})
+
+	res := make([]structField, 0, len(fields))
+	for _, field := range fields {
+		if field.name != "" {
+			res = append(res, field)
+		}
+	}
+	// fields are ordered like in the struct, embedded ones in place of the embedded struct
+	sort.Slice(res, func(i, j int) bool {
+		a, b := res[i].index, res[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	structFieldsCache.Store(t, res)
+	return res
+}
+
+func addStructFields(
+	t reflect.Type,
+	index []int,
+	depth int,
+	visited map[reflect.Type]bool,
+	add func(field structField, depth int, tagged bool),
+) {
+	if visited[t] {
+		return
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, options, tagged := fieldTag(f)
+		if name == "-" {
+			continue
+		}
+
+		fieldIndex := make([]int, len(index)+1)
+		copy(fieldIndex, index)
+		fieldIndex[len(index)] = i
+
+		if f.Anonymous && !tagged {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addStructFields(ft, fieldIndex, depth+1, visited, add)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		add(structField{
+			name:      name,
+			index:     fieldIndex,
+			omitEmpty: options["omitempty"],
+			redact:    hasRedactTag(f),
+		}, depth, tagged)
+	}
+}
+
+// fieldTag returns the name and options of the field taken from the first of
+// log, yaml and json tags defining the name
+func fieldTag(f reflect.StructField) (string, map[string]bool, bool) {
+	options := map[string]bool{}
+	var name string
+	for _, key := range []string{"log", "yaml", "json"} {
+		tag, ok := f.Tag.Lookup(key)
+		if !ok {
+			continue
+		}
+		tagName, tagOptions, _ := strings.Cut(tag, ",")
+		if key == "log" && tagName == "redact" {
+			// `log:"redact"` defines no name
+			tagName = ""
+		}
+		for _, option := range strings.Split(tagOptions, ",") {
+			if option != "" {
+				options[option] = true
+			}
+		}
+		if tagName != "" {
+			name = tagName
+			break
+		}
+	}
+	return name, options, name != ""
+}
+
+// fieldByIndex returns the field of the struct, the bool is false if it is
+// unreachable because of nil embedded pointer
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Ptr:
+		return v.IsZero()
+	}
+	return false
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,447 @@
+package logger
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/must"
+)
+
+const (
+	// FormatLogfmt causes logs to be printed in logfmt format
+	FormatLogfmt Format = "logfmt"
+
+	// FormatGCP causes logs to be printed in JSON format understood by Google Cloud Logging
+	FormatGCP Format = "gcp"
+
+	// FormatECS causes logs to be printed in JSON format following Elastic Common Schema
+	FormatECS Format = "ecs"
+)
+
+// ecsVersion is the version of Elastic Common Schema the logs conform to
+const ecsVersion = "1.6.0"
+
+func init() {
+	for _, format := range []Format{FormatLogfmt, FormatGCP, FormatECS} {
+		newEnc := encoders[format]
+		must.OK(zap.RegisterEncoder(string(format), func(config zapcore.EncoderConfig) (zapcore.Encoder, error) {
+			return newEnc(config, false), nil
+		}))
+	}
+}
+
+// ********** Google Cloud Logging **********
+
+func newGCPEncoder(config zapcore.EncoderConfig) zapcore.Encoder {
+	config.TimeKey = "time"
+	config.LevelKey = "severity"
+	config.NameKey = "logger"
+	config.CallerKey = zapcore.OmitKey
+	config.FunctionKey = zapcore.OmitKey
+	config.MessageKey = "message"
+	config.StacktraceKey = "stack_trace"
+	config.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	config.EncodeLevel = gcpSeverityEncoder
+	return &gcpEncoder{Encoder: zapcore.NewJSONEncoder(config)}
+}
+
+// gcpEncoder adds source location and moves stack of the error to the stack
+// trace, so the entry is picked by Error Reporting. Stack of the first error
+// having one is more relevant than the one of the entry, so it replaces it.
+type gcpEncoder struct {
+	zapcore.Encoder
+}
+
+func (e *gcpEncoder) Clone() zapcore.Encoder {
+	return &gcpEncoder{Encoder: e.Encoder.Clone()}
+}
+
+func (e *gcpEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	res := make([]zapcore.Field, 0, len(fields)+1)
+	var errStack bool
+	for _, field := range fields {
+		err, ok := fieldError(field)
+		if !ok {
+			res = append(res, field)
+			continue
+		}
+		res = append(res, zap.String(field.Key, err.Error()))
+		if stack := errorStack(err); stack != "" && !errStack {
+			entry.Stack = stack
+			errStack = true
+		}
+	}
+	if entry.Caller.Defined {
+		res = append(res, zap.Object("logging.googleapis.com/sourceLocation", gcpSourceLocation(entry.Caller)))
+	}
+	return e.Encoder.EncodeEntry(entry, res)
+}
+
+func gcpSeverityEncoder(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	switch level {
+	case zapcore.DebugLevel:
+		enc.AppendString("DEBUG")
+	case zapcore.InfoLevel:
+		enc.AppendString("INFO")
+	case zapcore.WarnLevel:
+		enc.AppendString("WARNING")
+	case zapcore.ErrorLevel:
+		enc.AppendString("ERROR")
+	case zapcore.DPanicLevel:
+		enc.AppendString("CRITICAL")
+	case zapcore.PanicLevel:
+		enc.AppendString("ALERT")
+	case zapcore.FatalLevel:
+		enc.AppendString("EMERGENCY")
+	default:
+		enc.AppendString("DEFAULT")
+	}
+}
+
+type gcpSourceLocation zapcore.EntryCaller
+
+func (l gcpSourceLocation) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("file", l.File)
+	// line is a string in the LogEntrySourceLocation message
+	enc.AddString("line", strconv.Itoa(l.Line))
+	if l.Function != "" {
+		enc.AddString("function", l.Function)
+	}
+	return nil
+}
+
+// ********** Elastic Common Schema **********
+
+func newECSEncoder(config zapcore.EncoderConfig) zapcore.Encoder {
+	config.TimeKey = "@timestamp"
+	config.LevelKey = "log.level"
+	config.NameKey = "log.logger"
+	config.CallerKey = zapcore.OmitKey
+	config.FunctionKey = zapcore.OmitKey
+	config.MessageKey = "message"
+	config.StacktraceKey = "error.stack_trace"
+	config.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	config.EncodeLevel = zapcore.LowercaseLevelEncoder
+
+	enc := zapcore.NewJSONEncoder(config)
+	enc.AddString("ecs.version", ecsVersion)
+	return &ecsEncoder{Encoder: enc}
+}
+
+// ecsEncoder adds origin of the entry and puts the error into error.* fields
+type ecsEncoder struct {
+	zapcore.Encoder
+}
+
+func (e *ecsEncoder) Clone() zapcore.Encoder {
+	return &ecsEncoder{Encoder: e.Encoder.Clone()}
+}
+
+func (e *ecsEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	res := make([]zapcore.Field, 0, len(fields)+3)
+	if entry.Caller.Defined {
+		res = append(res,
+			zap.String("log.origin.file.name", entry.Caller.File),
+			zap.Int("log.origin.file.line", entry.Caller.Line),
+		)
+		if entry.Caller.Function != "" {
+			res = append(res, zap.String("log.origin.function", entry.Caller.Function))
+		}
+	}
+	for _, field := range fields {
+		err, ok := fieldError(field)
+		switch {
+		case !ok:
+			res = append(res, field)
+		case field.Key != "error":
+			res = append(res, zap.String(field.Key, err.Error()))
+		default:
+			res = append(res,
+				zap.String("error.message", err.Error()),
+				zap.String("error.type", fmt.Sprintf("%T", err)),
+			)
+			if stack := errorStack(err); stack != "" {
+				// stack of the error is more relevant than the one of the entry
+				entry.Stack = stack
+			}
+		}
+	}
+	return e.Encoder.EncodeEntry(entry, res)
+}
+
+func fieldError(field zapcore.Field) (error, bool) {
+	if field.Type != zapcore.ErrorType {
+		return nil, false
+	}
+	err, ok := field.Interface.(error)
+	return err, ok
+}
+
+// errorStack returns the error formatted with its stack trace if it has one
+func errorStack(err error) string {
+	if _, ok := err.(stackTracer); !ok {
+		return ""
+	}
+	return fmt.Sprintf("%+v", err)
+}
+
+// ********** logfmt **********
+
+func newLogfmtEncoder(config zapcore.EncoderConfig) zapcore.Encoder {
+	return &logfmt{
+		config: config,
+		buffer: bufPool.Get(),
+	}
+}
+
+// logfmt encodes entries as space-separated key=value pairs. Keys of nested
+// objects and namespaces are prefixed with the key of the parent, separated
+// by a dot. Arrays and reflected values are encoded as JSON.
+type logfmt struct {
+	config zapcore.EncoderConfig
+	buffer *buffer.Buffer
+	prefix string
+}
+
+func (l *logfmt) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	enc := zapcore.NewMapObjectEncoder()
+	if err := enc.AddArray(key, marshaler); err != nil {
+		return err
+	}
+	return l.AddReflected(key, enc.Fields[key])
+}
+
+func (l *logfmt) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	sub := &logfmt{
+		config: l.config,
+		buffer: l.buffer,
+		prefix: l.prefix + key + ".",
+	}
+	return marshaler.MarshalLogObject(sub)
+}
+
+func (l *logfmt) AddBinary(key string, value []byte) {
+	l.AddString(key, base64.StdEncoding.EncodeToString(value))
+}
+
+func (l *logfmt) AddByteString(key string, value []byte) {
+	l.AddString(key, string(value))
+}
+
+func (l *logfmt) AddBool(key string, value bool) {
+	l.addKey(key)
+	l.buffer.AppendBool(value)
+}
+
+func (l *logfmt) AddComplex128(key string, value complex128) {
+	l.addKey(key)
+	l.buffer.AppendString(strconv.FormatComplex(value, 'g', -1, 128))
+}
+
+func (l *logfmt) AddComplex64(key string, value complex64) {
+	l.addKey(key)
+	l.buffer.AppendString(strconv.FormatComplex(complex128(value), 'g', -1, 64))
+}
+
+func (l *logfmt) AddDuration(key string, value time.Duration) {
+	l.addKey(key)
+	l.buffer.AppendString(value.String())
+}
+
+func (l *logfmt) AddFloat64(key string, value float64) {
+	l.addKey(key)
+	l.appendFloat(value, 64)
+}
+
+func (l *logfmt) AddFloat32(key string, value float32) {
+	l.addKey(key)
+	l.appendFloat(float64(value), 32)
+}
+
+func (l *logfmt) AddInt(key string, value int) {
+	l.AddInt64(key, int64(value))
+}
+
+func (l *logfmt) AddInt64(key string, value int64) {
+	l.addKey(key)
+	l.buffer.AppendInt(value)
+}
+
+func (l *logfmt) AddInt32(key string, value int32) {
+	l.AddInt64(key, int64(value))
+}
+
+func (l *logfmt) AddInt16(key string, value int16) {
+	l.AddInt64(key, int64(value))
+}
+
+func (l *logfmt) AddInt8(key string, value int8) {
+	l.AddInt64(key, int64(value))
+}
+
+func (l *logfmt) AddString(key, value string) {
+	l.addKey(key)
+	l.appendValue(value)
+}
+
+func (l *logfmt) AddTime(key string, value time.Time) {
+	l.addKey(key)
+	l.buffer.AppendTime(value, time.RFC3339Nano)
+}
+
+func (l *logfmt) AddUint(key string, value uint) {
+	l.AddUint64(key, uint64(value))
+}
+
+func (l *logfmt) AddUint64(key string, value uint64) {
+	l.addKey(key)
+	l.buffer.AppendUint(value)
+}
+
+func (l *logfmt) AddUint32(key string, value uint32) {
+	l.AddUint64(key, uint64(value))
+}
+
+func (l *logfmt) AddUint16(key string, value uint16) {
+	l.AddUint64(key, uint64(value))
+}
+
+func (l *logfmt) AddUint8(key string, value uint8) {
+	l.AddUint64(key, uint64(value))
+}
+
+func (l *logfmt) AddUintptr(key string, value uintptr) {
+	l.AddUint64(key, uint64(value))
+}
+
+func (l *logfmt) AddReflected(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		// strings are not quoted unless needed
+		l.AddString(key, str)
+		return nil
+	}
+	l.AddString(key, string(data))
+	return nil
+}
+
+func (l *logfmt) OpenNamespace(key string) {
+	l.prefix += key + "."
+}
+
+func (l *logfmt) Clone() zapcore.Encoder {
+	buf := bufPool.Get()
+	must.Any(buf.Write(l.buffer.Bytes()))
+	return &logfmt{
+		config: l.config,
+		buffer: buf,
+		prefix: l.prefix,
+	}
+}
+
+func (l *logfmt) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	line := &logfmt{
+		config: l.config,
+		buffer: bufPool.Get(),
+	}
+
+	if l.config.TimeKey != zapcore.OmitKey && !entry.Time.IsZero() {
+		line.AddTime(l.config.TimeKey, entry.Time)
+	}
+	if l.config.LevelKey != zapcore.OmitKey {
+		line.AddString(l.config.LevelKey, entry.Level.String())
+	}
+	if l.config.NameKey != zapcore.OmitKey && entry.LoggerName != "" {
+		line.AddString(l.config.NameKey, entry.LoggerName)
+	}
+	if l.config.CallerKey != zapcore.OmitKey && entry.Caller.Defined {
+		line.AddString(l.config.CallerKey, entry.Caller.TrimmedPath())
+	}
+	if l.config.MessageKey != zapcore.OmitKey {
+		line.AddString(l.config.MessageKey, entry.Message)
+	}
+
+	if l.buffer.Len() > 0 {
+		line.buffer.AppendByte(' ')
+		must.Any(line.buffer.Write(l.buffer.Bytes()))
+	}
+	line.prefix = l.prefix
+	for _, field := range fields {
+		field.AddTo(line)
+	}
+	line.prefix = ""
+
+	if l.config.StacktraceKey != zapcore.OmitKey && entry.Stack != "" {
+		line.AddString(l.config.StacktraceKey, entry.Stack)
+	}
+	line.buffer.AppendString(zapcore.DefaultLineEnding)
+	return line.buffer, nil
+}
+
+func (l *logfmt) addKey(key string) {
+	if l.buffer.Len() > 0 {
+		l.buffer.AppendByte(' ')
+	}
+	l.buffer.AppendString(logfmtKey(l.prefix + key))
+	l.buffer.AppendByte('=')
+}
+
+func (l *logfmt) appendFloat(value float64, bitSize int) {
+	switch {
+	case math.IsNaN(value):
+		l.buffer.AppendString("NaN")
+	case math.IsInf(value, 1):
+		l.buffer.AppendString("+Inf")
+	case math.IsInf(value, -1):
+		l.buffer.AppendString("-Inf")
+	default:
+		l.buffer.AppendFloat(value, bitSize)
+	}
+}
+
+func (l *logfmt) appendValue(value string) {
+	if logfmtNeedsQuoting(value) {
+		l.buffer.AppendString(strconv.Quote(value))
+		return
+	}
+	l.buffer.AppendString(value)
+}
+
+func logfmtNeedsQuoting(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == 0x7f || r == utf8.RuneError {
+			return true
+		}
+	}
+	return false
+}
+
+// logfmtKey replaces characters which are not allowed in keys
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7f {
+			return '_'
+		}
+		return r
+	}, key)
+}
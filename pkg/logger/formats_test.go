@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var formatsEntry = zapcore.Entry{
+	Level:      zapcore.ErrorLevel,
+	Time:       time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+	LoggerName: "app.parallel",
+	Message:    "Task failed",
+	Caller: zapcore.EntryCaller{
+		Defined:  true,
+		File:     "/src/parallel/group.go",
+		Line:     12,
+		Function: "parallel.(*Group).runTask",
+	},
+}
+
+func TestLogfmt(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := newFormatsTestLogger(t, FormatLogfmt, buf).With(zap.String("service", "updater"), zap.Namespace("task"))
+	log.Info("Task started",
+		zap.String("name", "fetch blocks"),
+		zap.Int("attempt", 2),
+		zap.Bool("retry", true),
+		zap.Duration("timeout", 1500*time.Millisecond),
+		zap.Strings("peers", []string{"a", "b"}),
+		zap.Object("config", object2{Field1: `quoted "value"`, Field2: 3}),
+		zap.String("empty", ""),
+		zap.String("key with=space", "x"),
+	)
+
+	line := buf.String()
+	require.Equal(t, "level=info msg=\"Task started\" service=updater task.name=\"fetch blocks\" task.attempt=2 "+
+		"task.retry=true task.timeout=1.5s task.peers=\"[\\\"a\\\",\\\"b\\\"]\" "+
+		"task.config.field1=\"quoted \\\"value\\\"\" task.config.field2=3 task.empty=\"\" task.key_with_space=x\n",
+		stripLogfmtHeader(t, line))
+}
+
+func TestGCP(t *testing.T) {
+	encoded := encodeFormatsEntry(t, FormatGCP, zap.Error(errors.New("connection refused")), zap.Int("attempt", 2))
+
+	require.Equal(t, "ERROR", encoded["severity"])
+	require.Equal(t, "2023-01-02T03:04:05Z", encoded["time"])
+	require.Equal(t, "Task failed", encoded["message"])
+	require.Equal(t, "app.parallel", encoded["logger"])
+	require.Equal(t, "connection refused", encoded["error"])
+	require.EqualValues(t, 2, encoded["attempt"])
+	require.Contains(t, encoded["stack_trace"], "connection refused\n")
+	require.Contains(t, encoded["stack_trace"], "TestGCP")
+	require.Equal(t, map[string]interface{}{
+		"file":     "/src/parallel/group.go",
+		"line":     "12",
+		"function": "parallel.(*Group).runTask",
+	}, encoded["logging.googleapis.com/sourceLocation"])
+	require.NotContains(t, encoded, "errorVerbose")
+}
+
+func TestECS(t *testing.T) {
+	encoded := encodeFormatsEntry(t, FormatECS, zap.Error(errors.New("connection refused")), zap.Int("attempt", 2))
+
+	require.Equal(t, ecsVersion, encoded["ecs.version"])
+	require.Equal(t, "error", encoded["log.level"])
+	require.Equal(t, "2023-01-02T03:04:05Z", encoded["@timestamp"])
+	require.Equal(t, "Task failed", encoded["message"])
+	require.Equal(t, "app.parallel", encoded["log.logger"])
+	require.Equal(t, "/src/parallel/group.go", encoded["log.origin.file.name"])
+	require.EqualValues(t, 12, encoded["log.origin.file.line"])
+	require.Equal(t, "parallel.(*Group).runTask", encoded["log.origin.function"])
+	require.Equal(t, "connection refused", encoded["error.message"])
+	require.Equal(t, "*errors.fundamental", encoded["error.type"])
+	require.Contains(t, encoded["error.stack_trace"], "TestECS")
+	require.EqualValues(t, 2, encoded["attempt"])
+}
+
+func TestFormatsPreferErrorStack(t *testing.T) {
+	for format, stackKey := range map[Format]string{FormatGCP: "stack_trace", FormatECS: "error.stack_trace"} {
+		for _, level := range []zapcore.Level{zapcore.InfoLevel, zapcore.WarnLevel, zapcore.ErrorLevel} {
+			buf := &bytes.Buffer{}
+			log := newFormatsTestLogger(t, format, buf).WithOptions(zap.AddStacktrace(zapcore.WarnLevel))
+			log.Log(level, "Task failed", zap.Error(newStackError()))
+
+			encoded := map[string]interface{}{}
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &encoded))
+			require.Contains(t, encoded[stackKey], "connection refused\n", "%s %s", format, level)
+			require.Contains(t, encoded[stackKey], "newStackError", "%s %s", format, level)
+		}
+	}
+}
+
+func newStackError() error {
+	return errors.New("connection refused")
+}
+
+func TestFormatsRegistered(t *testing.T) {
+	for _, format := range []Format{FormatLogfmt, FormatGCP, FormatECS} {
+		cfg := zap.NewProductionConfig()
+		cfg.Encoding = string(format)
+		cfg.OutputPaths = []string{"stderr"}
+		_, err := cfg.Build()
+		require.NoError(t, err, format)
+	}
+}
+
+func encodeFormatsEntry(t *testing.T, format Format, fields ...zapcore.Field) map[string]interface{} {
+	encoder, err := NewEncoder(format, EncoderConfig, false)
+	require.NoError(t, err)
+	buf, err := encoder.EncodeEntry(formatsEntry, fields)
+	require.NoError(t, err)
+	defer buf.Free()
+
+	encoded := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &encoded))
+	return encoded
+}
+
+func newFormatsTestLogger(t *testing.T, format Format, buf *bytes.Buffer) *zap.Logger {
+	encoder, err := NewEncoder(format, EncoderConfig, false)
+	require.NoError(t, err)
+	return zap.New(zapcore.NewCore(encoder, zapcore.AddSync(buf), zapcore.DebugLevel))
+}
+
+// stripLogfmtHeader removes the timestamp which is different in each run
+func stripLogfmtHeader(t *testing.T, line string) string {
+	require.True(t, strings.HasPrefix(line, "ts="))
+	return line[strings.IndexByte(line, ' ')+1:]
+}
@@ -0,0 +1,236 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type levelsFieldType int
+
+const levelsField levelsFieldType = iota
+
+// Levels controls levels of the logger at runtime. Besides the level applied to
+// all the messages, it contains overrides applied to the messages logged by
+// named loggers.
+//
+// An override applies to a logger if its key is a sequence of consecutive
+// segments of the logger name, so override "parallel" applies to loggers named
+// "parallel", "app.parallel" and "app.parallel.group". If many overrides apply,
+// the longest one wins.
+type Levels struct {
+	level zap.AtomicLevel
+
+	mu        sync.RWMutex
+	overrides map[string]zapcore.Level
+	minLevel  zapcore.Level
+}
+
+// NewLevels creates new levels set to the given level and overrides.
+func NewLevels(level zapcore.Level, overrides map[string]zapcore.Level) *Levels {
+	l := &Levels{
+		level: zap.NewAtomicLevelAt(level),
+	}
+	l.SetOverrides(overrides)
+	return l
+}
+
+// Level returns the level applied to all the messages.
+func (l *Levels) Level() zapcore.Level {
+	return l.level.Level()
+}
+
+// SetLevel sets the level applied to all the messages.
+func (l *Levels) SetLevel(level zapcore.Level) {
+	l.level.SetLevel(level)
+}
+
+// Overrides returns copy of the level overrides.
+func (l *Levels) Overrides() map[string]zapcore.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	overrides := make(map[string]zapcore.Level, len(l.overrides))
+	for name, level := range l.overrides {
+		overrides[name] = level
+	}
+	return overrides
+}
+
+// SetOverrides replaces the level overrides.
+func (l *Levels) SetOverrides(overrides map[string]zapcore.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.overrides = make(map[string]zapcore.Level, len(overrides))
+	l.minLevel = zapcore.InvalidLevel
+	for name, level := range overrides {
+		l.overrides[name] = level
+		if l.minLevel == zapcore.InvalidLevel || level < l.minLevel {
+			l.minLevel = level
+		}
+	}
+}
+
+// Enabled returns true if message at the given level logged by the named
+// logger should be written.
+func (l *Levels) Enabled(name string, level zapcore.Level) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var match string
+	var matched bool
+	for key := range l.overrides {
+		longer := len(key) > len(match) || (len(key) == len(match) && key < match)
+		if (!matched || longer) && nameMatches(name, key) {
+			match = key
+			matched = true
+		}
+	}
+	if matched {
+		return level >= l.overrides[match]
+	}
+	return l.level.Enabled(level)
+}
+
+// mayBeEnabled returns true if message at the given level might be written by
+// any logger
+func (l *Levels) mayBeEnabled(level zapcore.Level) bool {
+	if l.level.Enabled(level) {
+		return true
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.minLevel != zapcore.InvalidLevel && level >= l.minLevel
+}
+
+// ServeHTTP allows to inspect and change levels over HTTP. GET returns current
+// levels, PUT changes them. Both use the JSON document:
+//
+//	{"level": "info", "overrides": {"parallel": "warn", "app.updater": "debug"}}
+//
+// Fields missing in the PUT request are not changed.
+func (l *Levels) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	type payload struct {
+		Level     *zapcore.Level           `json:"level,omitempty"`
+		Overrides map[string]zapcore.Level `json:"overrides,omitempty"`
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req payload
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if req.Level != nil {
+			l.SetLevel(*req.Level)
+		}
+		if req.Overrides != nil {
+			l.SetOverrides(req.Overrides)
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed: " + r.Method})
+		return
+	}
+
+	level := l.Level()
+	writeJSON(w, http.StatusOK, payload{Level: &level, Overrides: l.Overrides()})
+}
+
+// ParseLevelOverrides parses level overrides in the form used by the
+// --log-levels flag: "parallel=warn,app.updater=debug".
+func ParseLevelOverrides(value string) (map[string]zapcore.Level, error) {
+	overrides := map[string]zapcore.Level{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, levelStr, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, errors.Errorf("invalid level override %q, expected name=level", item)
+		}
+		level, err := zapcore.ParseLevel(strings.TrimSpace(levelStr))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid level override %q", item)
+		}
+		overrides[name] = level
+	}
+	return overrides, nil
+}
+
+// FormatLevelOverrides formats level overrides in the form used by the
+// --log-levels flag.
+func FormatLevelOverrides(overrides map[string]zapcore.Level) string {
+	items := make([]string, 0, len(overrides))
+	for name, level := range overrides {
+		items = append(items, name+"="+level.String())
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
+
+// WithLevels adds levels to context
+func WithLevels(ctx context.Context, levels *Levels) context.Context {
+	return context.WithValue(ctx, levelsField, levels)
+}
+
+// GetLevels gets levels from context
+func GetLevels(ctx context.Context) *Levels {
+	levels, _ := ctx.Value(levelsField).(*Levels)
+	return levels
+}
+
+func nameMatches(name, key string) bool {
+	return name == key ||
+		strings.HasPrefix(name, key+".") ||
+		strings.HasSuffix(name, "."+key) ||
+		strings.Contains(name, "."+key+".")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// levelCore filters entries using levels
+type levelCore struct {
+	zapcore.Core
+	levels *Levels
+
+	// close closes the outputs, it is shared by the cores derived by With
+	close func() error
+}
+
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.levels.mayBeEnabled(level) && c.Core.Enabled(level)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{
+		Core:   c.Core.With(fields),
+		levels: c.levels,
+		close:  c.close,
+	}
+}
+
+func (c *levelCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.levels.Enabled(entry.LoggerName, entry.Level) {
+		return ce
+	}
+	return c.Core.Check(entry, ce)
+}
@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestParseLevelOverrides(t *testing.T) {
+	overrides, err := ParseLevelOverrides(" parallel=warn, app.updater=DEBUG ,")
+	require.NoError(t, err)
+	require.Equal(t, map[string]zapcore.Level{
+		"parallel":    zapcore.WarnLevel,
+		"app.updater": zapcore.DebugLevel,
+	}, overrides)
+	require.Equal(t, "app.updater=debug,parallel=warn", FormatLevelOverrides(overrides))
+
+	_, err = ParseLevelOverrides("parallel")
+	require.Error(t, err)
+	_, err = ParseLevelOverrides("parallel=loud")
+	require.Error(t, err)
+}
+
+func TestLevelsOverrides(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	levels := NewLevels(zapcore.InfoLevel, map[string]zapcore.Level{
+		"parallel":    zapcore.WarnLevel,
+		"app.updater": zapcore.DebugLevel,
+	})
+	log := zap.New(&levelCore{Core: core, levels: levels}).Named("app")
+
+	log.Debug("app debug")
+	log.Info("app info")
+	log.Named("parallel").Info("parallel info")
+	log.Named("parallel").Warn("parallel warn")
+	log.Named("updater").Debug("updater debug")
+	// the longest override, app.updater, wins
+	log.Named("updater").Named("parallel").Info("nested parallel info")
+	log.Named("updater2").Debug("updater2 debug")
+
+	levels.SetLevel(zapcore.DebugLevel)
+	levels.SetOverrides(nil)
+	log.Debug("app debug after change")
+	log.Named("parallel").Debug("parallel debug after change")
+
+	messages := []string{}
+	for _, entry := range logs.All() {
+		messages = append(messages, entry.Message)
+	}
+	require.Equal(t, []string{
+		"app info",
+		"parallel warn",
+		"updater debug",
+		"nested parallel info",
+		"app debug after change",
+		"parallel debug after change",
+	}, messages)
+}
+
+func TestLevelsHTTP(t *testing.T) {
+	levels := NewLevels(zapcore.InfoLevel, nil)
+
+	rec := httptest.NewRecorder()
+	levels.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/",
+		strings.NewReader(`{"level":"debug","overrides":{"parallel":"warn"}}`)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, zapcore.DebugLevel, levels.Level())
+	require.Equal(t, map[string]zapcore.Level{"parallel": zapcore.WarnLevel}, levels.Overrides())
+
+	rec = httptest.NewRecorder()
+	levels.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"level":"error"}`)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, map[string]zapcore.Level{"parallel": zapcore.WarnLevel}, levels.Overrides())
+
+	rec = httptest.NewRecorder()
+	levels.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"level":"error","overrides":{"parallel":"warn"}}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	levels.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"level":"loud"}`)))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	levels.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
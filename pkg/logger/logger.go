@@ -2,10 +2,18 @@ package logger
 
 import (
 	"context"
+	stderrors "errors"
+	"os"
+	"slices"
 	"sync"
+	"sync/atomic"
 
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/clock"
+	"github.com/CoreumFoundation/coreum-tools/pkg/trace"
 )
 
 type logFiedType int
@@ -14,6 +22,26 @@ const logField logFiedType = iota
 
 var mu sync.Mutex
 
+var defaultLogger atomic.Pointer[defaultLoggers]
+
+type defaultLoggers struct {
+	log        *zap.Logger
+	callerSkip *zap.Logger
+}
+
+// callerSkip makes the logger report the code calling the wrapper of the logger
+var callerSkip = zap.AddCallerSkip(1)
+
+func init() {
+	SetDefault(nil)
+}
+
+// Keys of the fields identifying the trace and span, see Get
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
 // EncoderConfig is the config of log encoder
 var EncoderConfig = zapcore.EncoderConfig{
 	TimeKey:        "ts",
@@ -32,44 +60,261 @@ var EncoderConfig = zapcore.EncoderConfig{
 
 // New creates new logger
 func New(config Config) *zap.Logger {
-	cfg := zap.Config{
-		Level:            zap.NewAtomicLevelAt(zap.InfoLevel),
-		Development:      true,
-		Encoding:         string(config.Format),
-		EncoderConfig:    EncoderConfig,
-		OutputPaths:      []string{"stderr"},
-		ErrorOutputPaths: []string{"stderr"},
-	}
+	log, _ := NewWithLevels(config)
+	return log
+}
+
+// NewWithLevels creates new logger and returns the levels controlling it at runtime
+func NewWithLevels(config Config) (*zap.Logger, *Levels) {
+	level := config.Level
 	if config.Verbose {
-		cfg.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
+		level = zap.DebugLevel
 	}
+	levels := NewLevels(level, config.LevelOverrides)
 
-	log, err := cfg.Build()
+	outputs := config.Outputs
+	if len(outputs) == 0 {
+		outputs = []Output{{Path: OutputStderr, Level: zapcore.DebugLevel}}
+	}
+	redactor, err := newRedactor(config.Redact)
 	if err != nil {
 		panic(err)
 	}
-	return log
+	cores := make([]zapcore.Core, 0, len(outputs))
+	closers := make([]func() error, 0, len(outputs))
+	for _, output := range outputs {
+		core, closeOutput, err := newOutputCore(output, config, redactor)
+		if err != nil {
+			for _, closeOutput := range closers {
+				_ = closeOutput()
+			}
+			panic(err)
+		}
+		cores = append(cores, core)
+		closers = append(closers, closeOutput)
+	}
+
+	core := zapcore.NewTee(cores...)
+	if config.Sampling.Enabled() {
+		core = newSamplingCore(core, config.Sampling, clock.Real())
+	}
+
+	closeOutputs := func() error {
+		var errs []error
+		for _, closeOutput := range closers {
+			errs = append(errs, closeOutput())
+		}
+		return stderrors.Join(errs...)
+	}
+
+	log := zap.New(&levelCore{Core: core, levels: levels, close: sync.OnceValue(closeOutputs)},
+		zap.Development(),
+		zap.AddCaller(),
+		zap.AddStacktrace(zap.WarnLevel),
+		zap.ErrorOutput(zapcore.Lock(os.Stderr)),
+	)
+	return log, levels
+}
+
+// Close syncs the logger created by New or NewWithLevels and closes the files
+// it writes to. Neither the logger nor the ones derived from it, e.g. by With,
+// might be used afterwards. Other loggers are only synced.
+func Close(log *zap.Logger) error {
+	err := errors.WithStack(log.Sync())
+	if core, ok := withoutTraceFields(log).Core().(*levelCore); ok {
+		err = stderrors.Join(err, core.close())
+	}
+	return err
+}
+
+// SetDefault sets the logger used by Get if context contains none. Nil
+// restores the default no-op logger.
+func SetDefault(log *zap.Logger) {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	defaultLogger.Store(&defaultLoggers{log: log, callerSkip: log.WithOptions(callerSkip)})
 }
 
-// With adds new logger to context
+// Default returns the logger used by Get if context contains none
+func Default() *zap.Logger {
+	return defaultLogger.Load().log
+}
+
+// DefaultCallerSkip returns the default logger like Default but skipping one
+// more caller, see LookupCallerSkip
+func DefaultCallerSkip() *zap.Logger {
+	return defaultLogger.Load().callerSkip
+}
+
+// With adds new logger to context. Fields are added to the default logger if
+// context contains none.
 func With(ctx context.Context, fields ...zap.Field) context.Context {
-	return context.WithValue(ctx, logField, Get(ctx).With(fields...))
+	cl, ok := get(ctx)
+	if !ok {
+		return context.WithValue(ctx, logField, &contextLogger{log: Default().With(fields...)})
+	}
+	res := &contextLogger{log: cl.log.With(fields...), span: cl.span}
+	if cl.traced != nil {
+		res.traced = cl.traced.With(fields...)
+	}
+	return context.WithValue(ctx, logField, res)
+}
+
+// WithTrace adds the fields identifying the span carried by the context, see
+// Get, to the logger of the context. It is called once the span is started,
+// so the fields are not added each time the logger is taken from the context.
+func WithTrace(ctx context.Context) context.Context {
+	sc, traced := trace.Get(ctx)
+	cl, ok := get(ctx)
+	if !traced || !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, logField, &contextLogger{
+		log:    cl.log,
+		traced: withTraceFields(cl.log, sc),
+		span:   sc.SpanID,
+	})
 }
 
-// Get gets logger from context
+// Get gets logger from context, the default logger is returned if context
+// contains none, so it is never nil. If context carries the trace context, the
+// returned logger adds trace_id and span_id fields to the entries.
 func Get(ctx context.Context) *zap.Logger {
+	log, ok := Lookup(ctx)
+	if !ok {
+		return Default()
+	}
+	return log
+}
+
+// Lookup gets logger from context like Get, false is returned if context
+// contains none
+func Lookup(ctx context.Context) (*zap.Logger, bool) {
+	cl, ok := get(ctx)
+	if !ok {
+		return nil, false
+	}
+	log, _ := cl.logger(ctx)
+	return log, true
+}
+
+// LookupCallerSkip gets logger from context like Lookup but skipping one more
+// caller, so wrappers of the logger, like parallel.ZapLogger, report the code
+// calling them. It is computed once for each logger stored in the context.
+func LookupCallerSkip(ctx context.Context) (*zap.Logger, bool) {
+	cl, ok := get(ctx)
+	if !ok {
+		return nil, false
+	}
+	log, current := cl.logger(ctx)
+	if !current {
+		return log.WithOptions(callerSkip), true
+	}
+	if skipped := cl.callerSkip.Load(); skipped != nil {
+		return skipped, true
+	}
+	skipped := log.WithOptions(callerSkip)
+	cl.callerSkip.Store(skipped)
+	return skipped, true
+}
+
+func get(ctx context.Context) (*contextLogger, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+
 	mu.Lock()
 	defer mu.Unlock()
 
-	log := ctx.Value(logField)
-	if log == nil {
-		return nil
+	cl, ok := ctx.Value(logField).(*contextLogger)
+	return cl, ok && cl.log != nil
+}
+
+// WithLogger adds existing logger to context. If the context is traced, the
+// fields identifying its span are added to the logger, like by WithTrace. Trace
+// fields the logger already has, e.g. if it has been returned by Get, are
+// replaced, so they are never duplicated.
+func WithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	cl := &contextLogger{log: withoutTraceFields(logger)}
+	if sc, traced := trace.Get(ctx); traced {
+		cl.traced = withTraceFields(cl.log, sc)
+		cl.span = sc.SpanID
 	}
+	return context.WithValue(ctx, logField, cl)
+}
 
-	return log.(*zap.Logger)
+// contextLogger is the logger stored in the context
+type contextLogger struct {
+	// log is the logger without trace fields, it is extended by With
+	log *zap.Logger
+
+	// traced is log with the fields of the span added by WithTrace, it is nil
+	// if the fields haven't been added
+	traced *zap.Logger
+	span   trace.SpanID
+
+	// callerSkip is the current logger skipping one more caller, computed once
+	callerSkip atomic.Pointer[zap.Logger]
 }
 
-// WithLogger adds existing logger to context
-func WithLogger(ctx context.Context, logger *zap.Logger) context.Context {
-	return context.WithValue(ctx, logField, logger)
+// logger returns the logger for the context, the bool is true if it is the
+// current one, precomputed for the span of the context
+func (cl *contextLogger) logger(ctx context.Context) (*zap.Logger, bool) {
+	sc, traced := trace.Get(ctx)
+	switch {
+	case !traced:
+		return cl.log, cl.traced == nil
+	case cl.traced != nil && cl.span == sc.SpanID:
+		return cl.traced, true
+	default:
+		// span has been started without calling WithTrace
+		return withTraceFields(cl.log, sc), false
+	}
+}
+
+func withTraceFields(log *zap.Logger, sc trace.SpanContext) *zap.Logger {
+	return log.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &tracedCore{
+			Core: core.With([]zapcore.Field{zap.Stringer(TraceIDKey, sc.TraceID), zap.Stringer(SpanIDKey, sc.SpanID)}),
+			base: core,
+		}
+	}))
+}
+
+// withoutTraceFields returns the logger without the fields added by withTraceFields
+func withoutTraceFields(log *zap.Logger) *zap.Logger {
+	core, ok := log.Core().(*tracedCore)
+	if !ok {
+		return log
+	}
+	return log.WithOptions(zap.WrapCore(func(zapcore.Core) zapcore.Core {
+		return core.untraced()
+	}))
+}
+
+// tracedCore is the core of the logger with trace fields, it remembers the core
+// without them, so they might be replaced, see WithLogger
+type tracedCore struct {
+	zapcore.Core
+
+	// base is the core without trace fields, fields are the ones added to the
+	// traced core later, they are added to base only if needed
+	base   zapcore.Core
+	fields []zapcore.Field
+}
+
+func (c *tracedCore) With(fields []zapcore.Field) zapcore.Core {
+	return &tracedCore{
+		Core:   c.Core.With(fields),
+		base:   c.base,
+		fields: append(slices.Clip(c.fields), fields...),
+	}
+}
+
+func (c *tracedCore) untraced() zapcore.Core {
+	if len(c.fields) == 0 {
+		return c.base
+	}
+	return c.base.With(c.fields)
 }
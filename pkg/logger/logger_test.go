@@ -0,0 +1,154 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/trace"
+)
+
+func TestGetAddsTraceFields(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctx := WithLogger(context.Background(), zap.New(core))
+
+	Get(ctx).Info("untraced")
+	require.Empty(t, logs.TakeAll()[0].Context)
+
+	ctx, _ = trace.Start(ctx, "test")
+	ctx = With(ctx, zap.String("component", "updater"))
+	ctx, _ = trace.StartChild(ctx, "child")
+	sc, _ := trace.Get(ctx)
+	Get(ctx).Info("traced")
+
+	entries := logs.TakeAll()
+	require.Len(t, entries, 1)
+	// fields are added once, for the current span, even if logger was extended in the parent span
+	require.Equal(t, map[string]interface{}{
+		"component": "updater",
+		TraceIDKey:  sc.TraceID.String(),
+		SpanIDKey:   sc.SpanID.String(),
+	}, entries[0].ContextMap())
+	require.Len(t, entries[0].Context, 3)
+}
+
+func TestWithTraceAddsFieldsOnce(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctx := WithLogger(context.Background(), zap.New(core))
+
+	// untraced logger is returned as is
+	require.Same(t, Get(ctx), Get(ctx))
+	skipped, ok := LookupCallerSkip(ctx)
+	require.True(t, ok)
+	skipped2, _ := LookupCallerSkip(ctx)
+	require.Same(t, skipped, skipped2)
+
+	ctx, _ = trace.Start(ctx, "test")
+	ctx = WithTrace(ctx)
+	sc, _ := trace.Get(ctx)
+	require.Same(t, Get(ctx), Get(ctx))
+	skipped, _ = LookupCallerSkip(ctx)
+	skipped2, _ = LookupCallerSkip(ctx)
+	require.Same(t, skipped, skipped2)
+
+	// fields added later are added to the logger with trace fields
+	ctx = With(ctx, zap.String("component", "updater"))
+	require.Same(t, Get(ctx), Get(ctx))
+	Get(ctx).Info("traced")
+	entries := logs.TakeAll()
+	require.Len(t, entries, 1)
+	require.Equal(t, map[string]interface{}{
+		"component": "updater",
+		TraceIDKey:  sc.TraceID.String(),
+		SpanIDKey:   sc.SpanID.String(),
+	}, entries[0].ContextMap())
+	require.Len(t, entries[0].Context, 3)
+
+	// child span started without WithTrace still gets its own fields
+	ctx, _ = trace.StartChild(ctx, "child")
+	child, _ := trace.Get(ctx)
+	Get(ctx).Info("child")
+	entries = logs.TakeAll()
+	require.Len(t, entries, 1)
+	require.Equal(t, child.SpanID.String(), entries[0].ContextMap()[SpanIDKey])
+	require.Len(t, entries[0].Context, 3)
+}
+
+func TestWithLoggerReplacesTraceFields(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctx, _ := trace.Start(context.Background(), "test")
+	sc, _ := trace.Get(ctx)
+
+	// trace fields are added without calling WithTrace
+	ctx = WithLogger(ctx, zap.New(core))
+	require.Same(t, Get(ctx), Get(ctx))
+
+	// logger taken from the traced context doesn't get the fields twice
+	ctx = WithLogger(ctx, Get(ctx).With(zap.String("component", "updater")))
+	Get(ctx).Info("traced")
+	entries := logs.TakeAll()
+	require.Len(t, entries, 1)
+	require.Equal(t, map[string]interface{}{
+		"component": "updater",
+		TraceIDKey:  sc.TraceID.String(),
+		SpanIDKey:   sc.SpanID.String(),
+	}, entries[0].ContextMap())
+	require.Len(t, entries[0].Context, 3)
+
+	// fields of another span are replaced
+	childCtx, _ := trace.StartChild(ctx, "child")
+	child, _ := trace.Get(childCtx)
+	childCtx = WithLogger(childCtx, Get(ctx))
+	Get(childCtx).Info("child")
+	entries = logs.TakeAll()
+	require.Len(t, entries, 1)
+	require.Equal(t, map[string]interface{}{
+		"component": "updater",
+		TraceIDKey:  child.TraceID.String(),
+		SpanIDKey:   child.SpanID.String(),
+	}, entries[0].ContextMap())
+	require.Len(t, entries[0].Context, 3)
+
+	// trace fields are removed if the context is not traced
+	Get(WithLogger(context.Background(), Get(ctx))).Info("untraced")
+	entries = logs.TakeAll()
+	require.Len(t, entries, 1)
+	require.Equal(t, map[string]interface{}{"component": "updater"}, entries[0].ContextMap())
+}
+
+func TestDefault(t *testing.T) {
+	ctx := context.Background()
+	_, ok := Lookup(ctx)
+	require.False(t, ok)
+	require.NotNil(t, Get(ctx))
+	// nil context must not panic
+	require.NotNil(t, Get(nil))
+	Get(ctx).Info("dropped")
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	SetDefault(zap.New(core))
+	t.Cleanup(func() {
+		SetDefault(nil)
+	})
+
+	Get(ctx).Info("default")
+	Get(With(ctx, zap.String("component", "updater"))).Info("with")
+	slog.New(NewSlogHandler(nil)).InfoContext(ctx, "slog")
+
+	entries := logs.TakeAll()
+	require.Len(t, entries, 3)
+	require.Equal(t, "default", entries[0].Message)
+	require.Equal(t, map[string]interface{}{"component": "updater"}, entries[1].ContextMap())
+	require.Equal(t, "slog", entries[2].Message)
+
+	// context logger takes precedence
+	ctxCore, ctxLogs := observer.New(zapcore.DebugLevel)
+	Get(WithLogger(ctx, zap.New(ctxCore))).Info("context")
+	require.Empty(t, logs.All())
+	require.Equal(t, 1, ctxLogs.Len())
+}
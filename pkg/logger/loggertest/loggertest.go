@@ -0,0 +1,166 @@
+// Package loggertest provides tools for testing code logging with the logger
+// taken from context.
+package loggertest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+)
+
+// Option is the option of captured logs
+type Option func(o *options)
+
+type options struct {
+	level         zapcore.Level
+	dumpOnFailure bool
+}
+
+// WithLevel is the option which sets the minimum level of captured entries.
+// Default is debug.
+func WithLevel(level zapcore.Level) Option {
+	return func(o *options) {
+		o.level = level
+	}
+}
+
+// WithDumpOnFailure is the option which writes captured entries in the YAML
+// format to the test log if the test fails
+func WithDumpOnFailure() Option {
+	return func(o *options) {
+		o.dumpOnFailure = true
+	}
+}
+
+// Logs are the entries captured in memory
+type Logs struct {
+	t        testing.TB
+	observed *observer.ObservedLogs
+}
+
+// New returns a copy of the context with the logger capturing entries in
+// memory, and the captured logs.
+//
+//	func TestService(t *testing.T) {
+//	    ctx, logs := loggertest.New(context.Background(), t, loggertest.WithDumpOnFailure())
+//	    ...
+//	    logs.RequireLogged(zapcore.ErrorLevel, "Request failed", zap.String("method", "GET"))
+//	}
+func New(ctx context.Context, t testing.TB, opts ...Option) (context.Context, *Logs) {
+	o := options{level: zapcore.DebugLevel}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	core, observed := observer.New(o.level)
+	logs := &Logs{t: t, observed: observed}
+	if o.dumpOnFailure {
+		t.Cleanup(func() {
+			if t.Failed() {
+				logs.dump()
+			}
+		})
+	}
+	return logger.WithLogger(ctx, zap.New(core, zap.AddCaller())), logs
+}
+
+// All returns all the captured entries
+func (l *Logs) All() []observer.LoggedEntry {
+	return l.observed.All()
+}
+
+// Find returns the entries logged at the level with the message, having all the
+// fields. Entries with any message are returned if msg is empty.
+func (l *Logs) Find(level zapcore.Level, msg string, fields ...zap.Field) []observer.LoggedEntry {
+	var res []observer.LoggedEntry
+	for _, entry := range l.observed.All() {
+		if entry.Level == level && (msg == "" || entry.Message == msg) && hasFields(entry, fields) {
+			res = append(res, entry)
+		}
+	}
+	return res
+}
+
+// RequireLogged fails the test if no entry logged at the level with the
+// message, having all the fields, was captured. Message is ignored if empty.
+func (l *Logs) RequireLogged(level zapcore.Level, msg string, fields ...zap.Field) {
+	l.t.Helper()
+
+	if len(l.Find(level, msg, fields...)) == 0 {
+		l.t.Fatalf("expected entry logged at %s%s was not captured", level, describe(msg, fields))
+	}
+}
+
+// RequireNotLogged fails the test if any entry logged at the level with the
+// message, having all the fields, was captured. Message is ignored if empty.
+func (l *Logs) RequireNotLogged(level zapcore.Level, msg string, fields ...zap.Field) {
+	l.t.Helper()
+
+	if entries := l.Find(level, msg, fields...); len(entries) > 0 {
+		l.t.Fatalf("unexpected entry logged at %s%s was captured: %q", level, describe(msg, fields), entries[0].Message)
+	}
+}
+
+func (l *Logs) dump() {
+	l.t.Helper()
+
+	entries := l.observed.All()
+	if len(entries) == 0 {
+		return
+	}
+	encoder, err := logger.NewEncoder(logger.FormatYAML, logger.EncoderConfig, false)
+	if err != nil {
+		l.t.Errorf("creating YAML encoder failed: %s", err)
+		return
+	}
+
+	dump := &strings.Builder{}
+	for _, entry := range entries {
+		buf, err := encoder.EncodeEntry(entry.Entry, entry.Context)
+		if err != nil {
+			l.t.Errorf("encoding entry %q failed: %s", entry.Message, err)
+			return
+		}
+		dump.Write(buf.Bytes())
+		buf.Free()
+	}
+	l.t.Logf("Captured logs:\n%s", dump)
+}
+
+func hasFields(entry observer.LoggedEntry, fields []zap.Field) bool {
+	for _, field := range fields {
+		found := false
+		for _, ctxField := range entry.Context {
+			if ctxField.Equals(field) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func describe(msg string, fields []zap.Field) string {
+	var res string
+	if msg != "" {
+		res += fmt.Sprintf(" with message %q", msg)
+	}
+	if len(fields) > 0 {
+		keys := make([]string, 0, len(fields))
+		for _, field := range fields {
+			keys = append(keys, field.Key)
+		}
+		res += " having fields " + strings.Join(keys, ", ")
+	}
+	return res
+}
@@ -0,0 +1,119 @@
+package loggertest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+)
+
+type fakeT struct {
+	testing.TB
+
+	failed   bool
+	logs     []string
+	cleanups []func()
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Fatalf(format string, args ...interface{}) {
+	t.failed = true
+	t.logs = append(t.logs, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.Fatalf(format, args...)
+}
+
+func (t *fakeT) Logf(format string, args ...interface{}) {
+	t.logs = append(t.logs, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeT) Failed() bool {
+	return t.failed
+}
+
+func (t *fakeT) Cleanup(f func()) {
+	t.cleanups = append(t.cleanups, f)
+}
+
+func (t *fakeT) finish() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+func TestRequireLogged(t *testing.T) {
+	ctx, logs := New(context.Background(), t)
+	ctx = logger.With(ctx, zap.String("component", "updater"))
+	err := errors.New("connection refused")
+	logger.Get(ctx).Info("Starting")
+	logger.Get(ctx).Error("Request failed", zap.String("method", "GET"), zap.Error(err))
+
+	require.Len(t, logs.All(), 2)
+	require.Len(t, logs.Find(zapcore.ErrorLevel, "", zap.String("component", "updater")), 1)
+	logs.RequireLogged(zapcore.InfoLevel, "Starting")
+	logs.RequireLogged(zapcore.ErrorLevel, "Request failed", zap.String("method", "GET"), zap.Error(err))
+	logs.RequireLogged(zapcore.ErrorLevel, "", zap.String("component", "updater"))
+	logs.RequireNotLogged(zapcore.ErrorLevel, "Starting")
+	logs.RequireNotLogged(zapcore.WarnLevel, "")
+}
+
+func TestRequireLoggedFails(t *testing.T) {
+	ft := &fakeT{}
+	ctx, logs := New(context.Background(), ft)
+	logger.Get(ctx).Error("Request failed", zap.String("method", "GET"))
+
+	logs.RequireLogged(zapcore.ErrorLevel, "Request failed", zap.String("method", "POST"))
+	require.True(t, ft.failed)
+	require.Equal(t, []string{`expected entry logged at error with message "Request failed" having fields method was not captured`}, ft.logs)
+
+	ft = &fakeT{}
+	logs.t = ft
+	logs.RequireNotLogged(zapcore.ErrorLevel, "")
+	require.True(t, ft.failed)
+	require.Equal(t, []string{`unexpected entry logged at error was captured: "Request failed"`}, ft.logs)
+}
+
+func TestWithLevel(t *testing.T) {
+	ctx, logs := New(context.Background(), t, WithLevel(zapcore.WarnLevel))
+	logger.Get(ctx).Info("Skipped")
+	logger.Get(ctx).Warn("Captured")
+
+	require.Len(t, logs.All(), 1)
+	logs.RequireLogged(zapcore.WarnLevel, "Captured")
+}
+
+func TestDumpOnFailure(t *testing.T) {
+	// passing test doesn't dump
+	ft := &fakeT{}
+	ctx, _ := New(context.Background(), ft, WithDumpOnFailure())
+	logger.Get(ctx).Info("Starting")
+	ft.finish()
+	require.Empty(t, ft.logs)
+
+	// failing test dumps
+	ft = &fakeT{}
+	ctx, logs := New(context.Background(), ft, WithDumpOnFailure())
+	logger.Get(ctx).Info("Starting", zap.String("component", "updater"))
+	logs.RequireLogged(zapcore.ErrorLevel, "Failed")
+	ft.finish()
+	require.Len(t, ft.logs, 2)
+	require.Contains(t, ft.logs[1], "Captured logs:\n")
+	require.Contains(t, ft.logs[1], "Starting")
+	require.Contains(t, ft.logs[1], `component: "updater"`)
+
+	// failing test without the option doesn't dump
+	ft = &fakeT{}
+	_, logs = New(context.Background(), ft)
+	logs.RequireLogged(zapcore.ErrorLevel, "Failed")
+	ft.finish()
+	require.Len(t, ft.logs, 1)
+}
@@ -0,0 +1,193 @@
+package logger
+
+import (
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// OutputStderr is the path of the output writing to the standard error
+	OutputStderr = "stderr"
+
+	// OutputStdout is the path of the output writing to the standard output
+	OutputStdout = "stdout"
+)
+
+// Output defines a destination of logs
+type Output struct {
+	// Path is the path of the log file, OutputStderr or OutputStdout
+	Path string
+
+	// Format is the format of log output, Config.Format is used if empty
+	Format Format
+
+	// Level is the minimal level of messages written to the output, on top of
+	// the levels of the logger. Like Config.Level, it is info by default, debug
+	// level writes all the messages. ParseOutput uses debug level if none is given.
+	Level zapcore.Level
+
+	// MaxSize is the size in bytes after which the log file is rotated, 0 turns
+	// off size-based rotation
+	MaxSize int64
+
+	// MaxAge is the age after which the log file is rotated, 0 turns off
+	// age-based rotation
+	MaxAge time.Duration
+
+	// MaxBackups is the number of rotated log files to keep, 0 keeps all
+	MaxBackups int
+
+	// Compress turns on gzip compression of rotated log files
+	Compress bool
+}
+
+// ParseOutput parses output in the form used by the --log-output flag: path
+// optionally followed by parameters in URL query form, e.g.
+//
+//	stderr?format=console&level=info
+//	/var/log/app.log?format=json&max-size=100MB&max-age=24h&max-backups=7&compress=true
+func ParseOutput(value string) (Output, error) {
+	path, query, _ := strings.Cut(value, "?")
+	output := Output{Path: path, Level: zapcore.DebugLevel}
+	if output.Path == "" {
+		return Output{}, errors.Errorf("output %q has no path", value)
+	}
+
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return Output{}, errors.Wrapf(err, "invalid parameters of output %q", value)
+	}
+	for key, values := range params {
+		param := values[len(values)-1]
+		switch key {
+		case "format":
+			output.Format = Format(param)
+		case "level":
+			err = output.Level.UnmarshalText([]byte(param))
+		case "max-size":
+			output.MaxSize, err = parseSize(param)
+		case "max-age":
+			output.MaxAge, err = time.ParseDuration(param)
+		case "max-backups":
+			output.MaxBackups, err = strconv.Atoi(param)
+		case "compress":
+			output.Compress, err = strconv.ParseBool(param)
+		default:
+			err = errors.New("unknown parameter")
+		}
+		if err != nil {
+			return Output{}, errors.Wrapf(err, "invalid parameter %q of output %q", key, value)
+		}
+	}
+	return output, nil
+}
+
+// String returns the output in the form accepted by ParseOutput.
+func (o Output) String() string {
+	params := url.Values{}
+	if o.Format != "" {
+		params.Set("format", string(o.Format))
+	}
+	if o.Level != zapcore.DebugLevel {
+		params.Set("level", o.Level.String())
+	}
+	if o.MaxSize > 0 {
+		params.Set("max-size", strconv.FormatInt(o.MaxSize, 10))
+	}
+	if o.MaxAge > 0 {
+		params.Set("max-age", o.MaxAge.String())
+	}
+	if o.MaxBackups > 0 {
+		params.Set("max-backups", strconv.Itoa(o.MaxBackups))
+	}
+	if o.Compress {
+		params.Set("compress", "true")
+	}
+	if len(params) == 0 {
+		return o.Path
+	}
+	return o.Path + "?" + params.Encode()
+}
+
+func (o Output) rotated() bool {
+	return o.MaxSize > 0 || o.MaxAge > 0
+}
+
+// newOutputCore returns the core writing to the output and the function closing it
+func newOutputCore(output Output, config Config, redactor *redactor) (zapcore.Core, func() error, error) {
+	format := output.Format
+	if format == "" {
+		format = config.Format
+	}
+	encoder, err := NewEncoder(format, EncoderConfig, config.Color.Enabled(output.Path))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var sink zapcore.WriteSyncer
+	closeSink := func() error { return nil }
+	switch {
+	case output.Path == OutputStderr:
+		sink = zapcore.Lock(os.Stderr)
+	case output.Path == OutputStdout:
+		sink = zapcore.Lock(os.Stdout)
+	case output.rotated():
+		var file *rotatingFile
+		file, err = newRotatingFile(output)
+		if err == nil {
+			sink = file
+			closeSink = file.Close
+		}
+	default:
+		var closeFile func()
+		sink, closeFile, err = zap.Open(output.Path)
+		if err == nil {
+			closeSink = func() error {
+				closeFile()
+				return nil
+			}
+		}
+	}
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "opening output %s failed", output.Path)
+	}
+
+	return &redactCore{
+		Core:     zapcore.NewCore(encoder, sink, output.Level),
+		redactor: redactor,
+	}, closeSink, nil
+}
+
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{suffix: "GB", bytes: 1 << 30},
+	{suffix: "MB", bytes: 1 << 20},
+	{suffix: "KB", bytes: 1 << 10},
+	{suffix: "B", bytes: 1},
+}
+
+func parseSize(value string) (int64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	unit := int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(value, u.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, u.suffix))
+			unit = u.bytes
+			break
+		}
+	}
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size < 0 {
+		return 0, errors.Errorf("invalid size %q", value)
+	}
+	return size * unit, nil
+}
@@ -0,0 +1,157 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestParseOutput(t *testing.T) {
+	output, err := ParseOutput("/var/log/app.log?format=json&level=info&max-size=10MB&max-age=24h&max-backups=3&compress=true")
+	require.NoError(t, err)
+	require.Equal(t, Output{
+		Path:       "/var/log/app.log",
+		Format:     FormatJSON,
+		Level:      zapcore.InfoLevel,
+		MaxSize:    10 << 20,
+		MaxAge:     24 * time.Hour,
+		MaxBackups: 3,
+		Compress:   true,
+	}, output)
+
+	parsed, err := ParseOutput(output.String())
+	require.NoError(t, err)
+	require.Equal(t, output, parsed)
+
+	output, err = ParseOutput("stderr")
+	require.NoError(t, err)
+	require.Equal(t, Output{Path: OutputStderr, Level: zapcore.DebugLevel}, output)
+	require.Equal(t, "stderr", output.String())
+
+	for _, spec := range []string{"", "?format=json", "stderr?color=red", "app.log?max-size=big", "app.log?max-age=1"} {
+		_, err := ParseOutput(spec)
+		require.Error(t, err, spec)
+	}
+}
+
+func TestMultipleOutputs(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "app.json")
+	yamlPath := filepath.Join(dir, "app.yaml")
+
+	log := New(Config{
+		Format: FormatConsole,
+		Outputs: []Output{
+			{Path: jsonPath, Format: FormatJSON},
+			{Path: yamlPath, Format: FormatYAML, Level: zapcore.WarnLevel},
+		},
+	})
+	log.Info("info message")
+	log.Warn("warn message")
+	require.NoError(t, log.Sync())
+
+	messages := []string{}
+	for _, line := range readLines(t, jsonPath) {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		messages = append(messages, entry["msg"].(string))
+	}
+	require.Equal(t, []string{"info message", "warn message"}, messages)
+
+	yamlLog, err := os.ReadFile(yamlPath)
+	require.NoError(t, err)
+	require.NotContains(t, string(yamlLog), "info message")
+	require.Contains(t, string(yamlLog), "warn message")
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	f, err := newRotatingFile(Output{Path: path, MaxSize: 10, MaxBackups: 2, Compress: true})
+	require.NoError(t, err)
+
+	for _, line := range []string{"line-1\n", "line-2\n", "line-3\n", "line-4\n"} {
+		// rotations within the same millisecond don't overwrite backups
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, f.Sync())
+	require.Equal(t, []string{"line-4"}, readLines(t, path))
+
+	// backups are compressed and removed before the file is closed
+	require.NoError(t, f.Close())
+	backups, err := filepath.Glob(filepath.Join(dir, "app-*.log.gz"))
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	uncompressed, err := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	require.NoError(t, err)
+	require.Empty(t, uncompressed)
+
+	// the oldest backup, containing line-1, is removed
+	lines := []string{}
+	for _, backup := range backups {
+		lines = append(lines, readGzipLines(t, backup)...)
+	}
+	require.Equal(t, []string{"line-2", "line-3"}, lines)
+}
+
+func TestClose(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	rotatedPath := filepath.Join(dir, "rotated.log")
+
+	log := New(Config{
+		Format: FormatJSON,
+		Outputs: []Output{
+			{Path: path, Level: zapcore.DebugLevel},
+			{Path: rotatedPath, Level: zapcore.DebugLevel, MaxSize: 1 << 20},
+		},
+	})
+	errOutput := &bytes.Buffer{}
+	log = log.WithOptions(zap.ErrorOutput(zapcore.AddSync(errOutput)))
+
+	log.Info("before close")
+	// loggers derived from the created one close its outputs too
+	require.NoError(t, Close(log.With(zap.String("key", "value"))))
+	require.Len(t, readLines(t, path), 1)
+	require.Len(t, readLines(t, rotatedPath), 1)
+
+	log.Info("after close")
+	require.Equal(t, 2, strings.Count(errOutput.String(), os.ErrClosed.Error()))
+	require.Len(t, readLines(t, path), 1)
+	require.Len(t, readLines(t, rotatedPath), 1)
+}
+
+func readLines(t *testing.T, path string) []string {
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSpace(string(content)), "\n")
+}
+
+func readGzipLines(t *testing.T, path string) []string {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	require.NoError(t, err)
+
+	lines := []string{}
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	require.NoError(t, scanner.Err())
+	return lines
+}
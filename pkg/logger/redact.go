@@ -0,0 +1,502 @@
+package logger
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RedactedValue replaces values of sensitive fields in logs
+const RedactedValue = "***"
+
+// DefaultRedactPatterns are the patterns of keys whose values are redacted by default
+var DefaultRedactPatterns = []string{
+	"*mnemonic*",
+	"*password*",
+	"*passphrase*",
+	"*secret*",
+	"*private*key*",
+	"*privkey*",
+	"*api*key*",
+	"*access*token*",
+	"*auth*token*",
+	"*refresh*token*",
+	"*authorization*",
+}
+
+// Secret is a string which is never written to logs, use it for values of
+// sensitive fields and struct fields:
+//
+//	log.Info("Connecting", zap.Any("password", logger.Secret(password)))
+type Secret string
+
+// String returns RedactedValue
+func (s Secret) String() string {
+	return RedactedValue
+}
+
+// GoString returns RedactedValue
+func (s Secret) GoString() string {
+	return RedactedValue
+}
+
+// Format prints RedactedValue for all the verbs
+func (s Secret) Format(f fmt.State, _ rune) {
+	_, _ = f.Write([]byte(RedactedValue))
+}
+
+// MarshalText returns RedactedValue
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(RedactedValue), nil
+}
+
+// redactor redacts values of sensitive fields. A value is redacted if its key
+// matches one of the patterns (case-insensitive, in path.Match syntax), if it
+// is a struct field tagged with `log:"redact"` or if it is of Secret type.
+type redactor struct {
+	patterns []string
+}
+
+func newRedactor(patterns []string) (*redactor, error) {
+	r := &redactor{patterns: make([]string, 0, len(patterns))}
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, errors.Wrapf(err, "invalid redaction pattern %q", pattern)
+		}
+		r.patterns = append(r.patterns, pattern)
+	}
+	return r, nil
+}
+
+func (r *redactor) matches(key string) bool {
+	if len(r.patterns) == 0 {
+		return false
+	}
+	key = strings.ToLower(key)
+	for _, pattern := range r.patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *redactor) fields(fields []zapcore.Field) []zapcore.Field {
+	if len(fields) == 0 {
+		return fields
+	}
+	res := make([]zapcore.Field, 0, len(fields))
+	for _, field := range fields {
+		res = append(res, r.field(field))
+	}
+	return res
+}
+
+func (r *redactor) field(field zapcore.Field) zapcore.Field {
+	switch field.Type {
+	case zapcore.NamespaceType, zapcore.SkipType:
+		return field
+	case zapcore.InlineMarshalerType:
+		return zap.Inline(redactedObject{marshaler: field.Interface.(zapcore.ObjectMarshaler), redactor: r})
+	}
+	if r.matches(field.Key) {
+		return zap.String(field.Key, RedactedValue)
+	}
+
+	switch field.Type {
+	case zapcore.ObjectMarshalerType:
+		return zap.Object(field.Key, redactedObject{marshaler: field.Interface.(zapcore.ObjectMarshaler), redactor: r})
+	case zapcore.ArrayMarshalerType:
+		return zap.Array(field.Key, redactedArray{marshaler: field.Interface.(zapcore.ArrayMarshaler), redactor: r})
+	case zapcore.ReflectType:
+		if value, ok := r.value(reflect.ValueOf(field.Interface), map[uintptr]reflect.Value{}); ok {
+			return zap.Reflect(field.Key, value.Interface())
+		}
+	}
+	return field
+}
+
+// value returns a copy of the value with sensitive fields redacted, the bool is
+// false if nothing is redacted and the original value should be used
+func (r *redactor) value(v reflect.Value, visited map[uintptr]reflect.Value) (reflect.Value, bool) {
+	if !v.IsValid() || v.Type() == secretType {
+		return v, false
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v, false
+		}
+		// pointers closing a cycle point to the copy being built, so the copy
+		// never refers back to the original value
+		if res, ok := visited[v.Pointer()]; ok {
+			return res, true
+		}
+		res := reflect.New(v.Type().Elem())
+		visited[v.Pointer()] = res
+		defer delete(visited, v.Pointer())
+
+		elem, ok := r.value(v.Elem(), visited)
+		if !ok {
+			return v, false
+		}
+		res.Elem().Set(elem)
+		return res, true
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, false
+		}
+		elem, ok := r.value(v.Elem(), visited)
+		if !ok {
+			return v, false
+		}
+		res := reflect.New(v.Type()).Elem()
+		res.Set(elem)
+		return res, true
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return v, false
+		}
+		var res reflect.Value
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			fv := v.Field(i)
+			var redacted reflect.Value
+			if name, _, _ := fieldTag(f); hasRedactTag(f) || r.matches(f.Name) || r.matches(name) {
+				redacted = redactedValue(f.Type)
+			} else if value, ok := r.value(fv, visited); ok {
+				redacted = value
+			} else {
+				continue
+			}
+			if !res.IsValid() {
+				res = reflect.New(t).Elem()
+				res.Set(v)
+			}
+			res.Field(i).Set(redacted)
+		}
+		return res, res.IsValid()
+	case reflect.Map:
+		if v.IsNil() {
+			return v, false
+		}
+		var res reflect.Value
+		iter := v.MapRange()
+		for iter.Next() {
+			var redacted reflect.Value
+			if key := iter.Key(); key.Kind() == reflect.String && r.matches(key.String()) {
+				redacted = redactedValue(v.Type().Elem())
+			} else if value, ok := r.value(iter.Value(), visited); ok {
+				redacted = value
+			} else {
+				continue
+			}
+			if !res.IsValid() {
+				res = reflect.MakeMapWithSize(v.Type(), v.Len())
+				copyIter := v.MapRange()
+				for copyIter.Next() {
+					res.SetMapIndex(copyIter.Key(), copyIter.Value())
+				}
+			}
+			res.SetMapIndex(iter.Key(), redacted)
+		}
+		return res, res.IsValid()
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return v, false
+		}
+		switch v.Type().Elem().Kind() {
+		case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+			reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+			// there is nothing to redact in sequences of scalars
+			return v, false
+		}
+		var res reflect.Value
+		for i := 0; i < v.Len(); i++ {
+			elem, ok := r.value(v.Index(i), visited)
+			if !ok {
+				continue
+			}
+			if !res.IsValid() {
+				if v.Kind() == reflect.Slice {
+					res = reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+					reflect.Copy(res, v)
+				} else {
+					res = reflect.New(v.Type()).Elem()
+					res.Set(v)
+				}
+			}
+			res.Index(i).Set(elem)
+		}
+		return res, res.IsValid()
+	default:
+		return v, false
+	}
+}
+
+var (
+	secretType = reflect.TypeOf(Secret(""))
+	timeType   = reflect.TypeOf(time.Time{})
+)
+
+// redactedValue returns the value replacing the redacted one of the type. It is
+// RedactedValue if the type can hold it and zero value otherwise.
+func redactedValue(t reflect.Type) reflect.Value {
+	res := reflect.New(t).Elem()
+	switch {
+	case t.Kind() == reflect.String:
+		res.SetString(RedactedValue)
+	case t.Kind() == reflect.Interface && secretType.Implements(t):
+		res.Set(reflect.ValueOf(Secret("")))
+	}
+	return res
+}
+
+func hasRedactTag(f reflect.StructField) bool {
+	for _, option := range strings.Split(f.Tag.Get("log"), ",") {
+		if option == "redact" {
+			return true
+		}
+	}
+	return false
+}
+
+type redactedObject struct {
+	marshaler zapcore.ObjectMarshaler
+	redactor  *redactor
+}
+
+func (o redactedObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return o.marshaler.MarshalLogObject(&redactObjectEncoder{ObjectEncoder: enc, redactor: o.redactor})
+}
+
+type redactedArray struct {
+	marshaler zapcore.ArrayMarshaler
+	redactor  *redactor
+}
+
+func (a redactedArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	return a.marshaler.MarshalLogArray(&redactArrayEncoder{ArrayEncoder: enc, redactor: a.redactor})
+}
+
+// redactCore redacts fields passed to the core
+type redactCore struct {
+	zapcore.Core
+	redactor *redactor
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{
+		Core:     c.Core.With(c.redactor.fields(fields)),
+		redactor: c.redactor,
+	}
+}
+
+func (c *redactCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, c.redactor.fields(fields))
+}
+
+// redactObjectEncoder redacts values added to the object by marshalers
+type redactObjectEncoder struct {
+	zapcore.ObjectEncoder
+	redactor *redactor
+}
+
+func (e *redactObjectEncoder) redact(key string) bool {
+	if e.redactor.matches(key) {
+		e.ObjectEncoder.AddString(key, RedactedValue)
+		return true
+	}
+	return false
+}
+
+func (e *redactObjectEncoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	if e.redact(key) {
+		return nil
+	}
+	return e.ObjectEncoder.AddArray(key, redactedArray{marshaler: marshaler, redactor: e.redactor})
+}
+
+func (e *redactObjectEncoder) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	if e.redact(key) {
+		return nil
+	}
+	return e.ObjectEncoder.AddObject(key, redactedObject{marshaler: marshaler, redactor: e.redactor})
+}
+
+func (e *redactObjectEncoder) AddBinary(key string, value []byte) {
+	if !e.redact(key) {
+		e.ObjectEncoder.AddBinary(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddByteString(key string, value []byte) {
+	if !e.redact(key) {
+		e.ObjectEncoder.AddByteString(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddBool(key string, value bool) {
+	if !e.redact(key) {
+		e.ObjectEncoder.AddBool(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddComplex128(key string, value complex128) {
+	if !e.redact(key) {
+		e.ObjectEncoder.AddComplex128(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddComplex64(key string, value complex64) {
+	if !e.redact(key) {
+		e.ObjectEncoder.AddComplex64(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddDuration(key string, value time.Duration) {
+	if !e.redact(key) {
+		e.ObjectEncoder.AddDuration(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddFloat64(key string, value float64) {
+	if !e.redact(key) {
+		e.ObjectEncoder.AddFloat64(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddFloat32(key string, value float32) {
+	if !e.redact(key) {
+		e.ObjectEncoder.AddFloat32(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddInt(key string, value int) {
+	if !e.redact(key) {
+		e.ObjectEncoder.AddInt(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddInt64(key string, value int64) {
+	if !e.redact(key) {
+		e.ObjectEncoder.AddInt64(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddInt32(key string, value int32) {
+	if !e.redact(key) {
+		e.ObjectEncoder.AddInt32(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddInt16(key string, value int16) {
+	if !e.redact(key) {
+		e.ObjectEncoder.AddInt16(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddInt8(key string, value int8) {
+	if !e.redact(key) {
+		e.ObjectEncoder.AddInt8(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddString(key, value string) {
+	if !e.redact(key) {
+		e.ObjectEncoder.AddString(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddTime(key string, value time.Time) {
+	if !e.redact(key) {
+		e.ObjectEncoder.AddTime(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddUint(key string, value uint) {
+	if !e.redact(key) {
+		e.ObjectEncoder.AddUint(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddUint64(key string, value uint64) {
+	if !e.redact(key) {
+		e.ObjectEncoder.AddUint64(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddUint32(key string, value uint32) {
+	if !e.redact(key) {
+		e.ObjectEncoder.AddUint32(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddUint16(key string, value uint16) {
+	if !e.redact(key) {
+		e.ObjectEncoder.AddUint16(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddUint8(key string, value uint8) {
+	if !e.redact(key) {
+		e.ObjectEncoder.AddUint8(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddUintptr(key string, value uintptr) {
+	if !e.redact(key) {
+		e.ObjectEncoder.AddUintptr(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddReflected(key string, value interface{}) error {
+	if e.redact(key) {
+		return nil
+	}
+	if redacted, ok := e.redactor.value(reflect.ValueOf(value), map[uintptr]reflect.Value{}); ok {
+		value = redacted.Interface()
+	}
+	return e.ObjectEncoder.AddReflected(key, value)
+}
+
+// redactArrayEncoder redacts values added to the array by marshalers
+type redactArrayEncoder struct {
+	zapcore.ArrayEncoder
+	redactor *redactor
+}
+
+func (e *redactArrayEncoder) AppendArray(marshaler zapcore.ArrayMarshaler) error {
+	return e.ArrayEncoder.AppendArray(redactedArray{marshaler: marshaler, redactor: e.redactor})
+}
+
+func (e *redactArrayEncoder) AppendObject(marshaler zapcore.ObjectMarshaler) error {
+	return e.ArrayEncoder.AppendObject(redactedObject{marshaler: marshaler, redactor: e.redactor})
+}
+
+func (e *redactArrayEncoder) AppendReflected(value interface{}) error {
+	if redacted, ok := e.redactor.value(reflect.ValueOf(value), map[uintptr]reflect.Value{}); ok {
+		value = redacted.Interface()
+	}
+	return e.ArrayEncoder.AppendReflected(value)
+}
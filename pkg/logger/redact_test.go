@@ -0,0 +1,120 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type redactConfig struct {
+	Name     string
+	Key      string `log:"redact"`
+	Seed     []byte `log:"redact"`
+	Password string
+	Token    Secret
+	Nested   *redactConfig
+	Extra    map[string]interface{}
+}
+
+type redactObject struct{}
+
+func (redactObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("user", "alice")
+	enc.AddString("apiKey", "key-value")
+	return enc.AddReflected("config", redactConfig{Key: "nested-key"})
+}
+
+func TestRedact(t *testing.T) {
+	for _, format := range []Format{FormatConsole, FormatJSON, FormatYAML} {
+		format := format
+		t.Run(string(format), func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			log := newRedactTestLogger(t, format, buf)
+
+			config := &redactConfig{
+				Name:     "service",
+				Key:      "private-key-value",
+				Seed:     []byte("seed-value"),
+				Password: "password-value",
+				Token:    "token-value",
+				Extra:    map[string]interface{}{"mnemonic": "mnemonic-value", "port": 8080},
+			}
+			config.Nested = &redactConfig{Name: "nested", Key: "nested-key-value"}
+
+			log.With(zap.String("db_password", "with-value")).Info("Config",
+				zap.Any("config", config),
+				zap.String("MNEMONIC", "field-mnemonic"),
+				zap.Stringer("token", Secret("secret-token")),
+				zap.Object("object", redactObject{}),
+				zap.String("user", "bob"),
+			)
+			require.NoError(t, log.Sync())
+
+			out := buf.String()
+			for _, secret := range []string{
+				"private-key-value", "seed-value", "password-value", "token-value", "mnemonic-value",
+				"nested-key", "with-value", "field-mnemonic", "secret-token", "key-value",
+			} {
+				require.NotContains(t, out, secret)
+			}
+			for _, visible := range []string{"service", "nested", "8080", "alice", "bob", RedactedValue} {
+				require.Contains(t, out, visible)
+			}
+		})
+	}
+
+	// the original value is not modified
+	config := redactConfig{Key: "key"}
+	log := newRedactTestLogger(t, FormatJSON, &bytes.Buffer{})
+	log.Info("Config", zap.Any("config", &config))
+	require.Equal(t, "key", config.Key)
+}
+
+func TestRedactCycle(t *testing.T) {
+	redactor, err := newRedactor(DefaultRedactPatterns)
+	require.NoError(t, err)
+
+	config := &redactConfig{Name: "outer", Key: "outer-key-value"}
+	config.Nested = &redactConfig{Name: "inner", Key: "inner-key-value", Nested: config}
+	redacted, ok := redactor.value(reflect.ValueOf(config), map[uintptr]reflect.Value{})
+	require.True(t, ok)
+	redactedConfig := redacted.Interface().(*redactConfig)
+	require.Equal(t, RedactedValue, redactedConfig.Key)
+	require.Equal(t, RedactedValue, redactedConfig.Nested.Key)
+	require.Same(t, redactedConfig, redactedConfig.Nested.Nested)
+
+	// only YAML encoder supports cycles
+	buf := &bytes.Buffer{}
+	log := newRedactTestLogger(t, FormatYAML, buf)
+	log.Info("Config", zap.Any("config", config))
+	require.NoError(t, log.Sync())
+
+	out := buf.String()
+	require.NotContains(t, out, "key-value")
+	require.Contains(t, out, "outer")
+	require.Contains(t, out, "inner")
+	require.Contains(t, out, "<cycle>")
+	require.Equal(t, "outer-key-value", config.Key)
+}
+
+func TestSecret(t *testing.T) {
+	secret := Secret("value")
+	require.Equal(t, RedactedValue, fmt.Sprint(secret))
+	require.NotContains(t, fmt.Sprintf("%s %v %#v %q %x", secret, secret, secret, secret, secret), "value")
+}
+
+func newRedactTestLogger(t *testing.T, format Format, buf *bytes.Buffer) *zap.Logger {
+	encoder, err := NewEncoder(format, EncoderConfig, false)
+	require.NoError(t, err)
+	redactor, err := newRedactor(DefaultRedactPatterns)
+	require.NoError(t, err)
+	return zap.New(&redactCore{
+		Core:     zapcore.NewCore(encoder, zapcore.AddSync(buf), zapcore.DebugLevel),
+		redactor: redactor,
+	})
+}
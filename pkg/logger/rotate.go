@@ -0,0 +1,228 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+)
+
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+var _ zapcore.WriteSyncer = &rotatingFile{}
+
+// rotatingFile is a log file which is rotated when it exceeds the maximum size
+// or age. Rotated files are renamed to <name>-<timestamp><ext>, optionally
+// compressed, and the oldest ones are removed.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+	compress   bool
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	// cleanupMu serializes compression and removal of backups done in background
+	cleanupMu sync.Mutex
+	cleanups  sync.WaitGroup
+}
+
+func newRotatingFile(output Output) (*rotatingFile, error) {
+	f := &rotatingFile{
+		path:       output.Path,
+		maxSize:    output.MaxSize,
+		maxAge:     output.MaxAge,
+		maxBackups: output.MaxBackups,
+		compress:   output.Compress,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// if rotation fails, the entry is still written to the current file
+	var rotateErr error
+	if f.shouldRotate(int64(len(p))) {
+		rotateErr = f.rotate()
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if err != nil {
+		return n, errors.WithStack(err)
+	}
+	return n, rotateErr
+}
+
+func (f *rotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return errors.WithStack(f.file.Sync())
+}
+
+// Close closes the file once the backups are compressed and removed.
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.cleanups.Wait()
+	return errors.WithStack(f.file.Close())
+}
+
+func (f *rotatingFile) shouldRotate(size int64) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.maxSize > 0 && f.size+size > f.maxSize {
+		return true
+	}
+	return f.maxAge > 0 && time.Since(f.openedAt) >= f.maxAge
+}
+
+func (f *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return errors.WithStack(err)
+	}
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return errors.WithStack(err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = time.Now()
+	if f.size > 0 {
+		// the file existed before, its age is estimated by modification time
+		f.openedAt = info.ModTime()
+	}
+	return nil
+}
+
+func (f *rotatingFile) rotate() error {
+	// the file is renamed before it is closed, so it is still written to if
+	// rotation fails
+	backup := f.backupPath()
+	if err := os.Rename(f.path, backup); err != nil {
+		return errors.WithStack(err)
+	}
+	file := f.file
+	if err := f.open(); err != nil {
+		// the file is moved back, so rotation is retried on the next write
+		_ = os.Rename(backup, f.path)
+		return err
+	}
+	err := file.Close()
+
+	f.cleanups.Add(1)
+	go f.cleanup(backup)
+	return errors.WithStack(err)
+}
+
+// backupPath returns the path of the next backup. Backups are named by time with
+// millisecond precision, so the time is moved forward if the backup rotated
+// within the same millisecond already exists.
+func (f *rotatingFile) backupPath() string {
+	ext := filepath.Ext(f.path)
+	prefix := strings.TrimSuffix(f.path, ext) + "-"
+	for t := time.Now().UTC(); ; t = t.Add(time.Millisecond) {
+		backup := prefix + t.Format(backupTimeFormat) + ext
+		if !fileExists(backup) && !fileExists(backup+".gz") {
+			return backup
+		}
+	}
+}
+
+// cleanup compresses the new backup and removes the ones exceeding the limit.
+// Errors are ignored because there is no place to report them.
+func (f *rotatingFile) cleanup(backup string) {
+	defer f.cleanups.Done()
+
+	f.cleanupMu.Lock()
+	defer f.cleanupMu.Unlock()
+
+	if f.compress {
+		_ = compressFile(backup)
+	}
+	if f.maxBackups <= 0 {
+		return
+	}
+
+	ext := filepath.Ext(f.path)
+	prefix := strings.TrimSuffix(filepath.Base(f.path), ext) + "-"
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return
+	}
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		timestamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz"), ext)
+		if _, err := time.Parse(backupTimeFormat, timestamp); err != nil {
+			continue
+		}
+		backups = append(backups, name)
+	}
+
+	// timestamps sort chronologically
+	sort.Strings(backups)
+	for len(backups) > f.maxBackups {
+		_ = os.Remove(filepath.Join(filepath.Dir(f.path), backups[0]))
+		backups = backups[1:]
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer dst.Close()
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := gz.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := dst.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.Remove(path))
+}
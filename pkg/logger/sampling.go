@@ -0,0 +1,151 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/clock"
+)
+
+// SamplingConfig configures sampling of repetitive log entries. Entries are
+// counted by message and level within each interval: the first Initial ones are
+// written, then every Thereafter-th one, and the rest is dropped.
+type SamplingConfig struct {
+	// Initial is the number of entries written in each interval before sampling starts,
+	// 0 turns sampling off
+	Initial int
+
+	// Thereafter defines that every Thereafter-th entry is written once Initial is exceeded,
+	// 0 drops all of them
+	Thereafter int
+
+	// Interval is the period after which counters are reset, 1 second is used if 0
+	Interval time.Duration
+}
+
+// Enabled returns true if sampling is turned on
+func (c SamplingConfig) Enabled() bool {
+	return c.Initial > 0
+}
+
+func newSamplingCore(core zapcore.Core, config SamplingConfig, clk clock.Clock) zapcore.Core {
+	if config.Interval <= 0 {
+		config.Interval = time.Second
+	}
+
+	summary := &samplingSummary{
+		core:     core,
+		interval: config.Interval,
+		clock:    clk,
+	}
+	return &samplingCore{
+		Core: zapcore.NewSamplerWithOptions(core, config.Interval, config.Initial, config.Thereafter,
+			zapcore.SamplerHook(func(_ zapcore.Entry, decision zapcore.SamplingDecision) {
+				if decision&zapcore.LogDropped != 0 {
+					summary.drop()
+				}
+			})),
+		summary: summary,
+	}
+}
+
+// samplingCore samples entries and reports the number of dropped ones. The
+// report is written once the interval passes since the first entry dropped
+// after the previous report, and on Sync.
+type samplingCore struct {
+	zapcore.Core
+	summary *samplingSummary
+}
+
+func (c *samplingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &samplingCore{
+		Core:    c.Core.With(fields),
+		summary: c.summary,
+	}
+}
+
+func (c *samplingCore) Sync() error {
+	c.summary.report()
+	return c.Core.Sync()
+}
+
+type samplingSummary struct {
+	core     zapcore.Core
+	interval time.Duration
+	clock    clock.Clock
+	dropped  atomic.Int64
+
+	mu sync.Mutex
+	// start is the time the report has been scheduled at, so the period starts
+	// with the first entry dropped after the previous report
+	start time.Time
+	// timer and cancel stop the scheduled report, they are nil if none is scheduled
+	timer  clock.Timer
+	cancel chan struct{}
+}
+
+func (s *samplingSummary) drop() {
+	if s.dropped.Add(1) == 1 {
+		s.schedule()
+	}
+}
+
+// schedule starts the timer writing the report once the interval passes, so
+// it is written even if no more entries are logged
+func (s *samplingSummary) schedule() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return
+	}
+	timer := s.clock.NewTimer(s.interval)
+	cancel := make(chan struct{})
+	s.start = s.clock.Now()
+	s.timer = timer
+	s.cancel = cancel
+	go func() {
+		select {
+		case <-timer.C():
+			s.report()
+		case <-cancel:
+		}
+	}()
+}
+
+func (s *samplingSummary) report() {
+	s.mu.Lock()
+	now := s.clock.Now()
+	// if the report hasn't been scheduled yet, entries have just been dropped
+	start := now
+	if s.timer != nil {
+		s.timer.Stop()
+		close(s.cancel)
+		s.timer = nil
+		s.cancel = nil
+		start = s.start
+	}
+	dropped := s.dropped.Swap(0)
+	s.mu.Unlock()
+	if dropped == 0 {
+		return
+	}
+	period := now.Sub(start)
+
+	ce := s.core.Check(zapcore.Entry{
+		Level:   zapcore.WarnLevel,
+		Time:    now,
+		Message: "Log entries dropped by sampling",
+	}, nil)
+	if ce == nil {
+		return
+	}
+	ce.Write(
+		zap.Int64("dropped", dropped),
+		zap.Duration("period", period),
+	)
+}
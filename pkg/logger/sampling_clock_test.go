@@ -0,0 +1,70 @@
+package logger_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+	"github.com/CoreumFoundation/coreum-tools/pkg/parallel/paralleltest"
+)
+
+const summaryMessage = "Log entries dropped by sampling"
+
+func TestSamplingSummaryAfterInterval(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+
+	fakeClock := paralleltest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := zap.New(logger.NewSamplingCore(core, logger.SamplingConfig{
+		Initial:  1,
+		Interval: time.Minute,
+	}, fakeClock), zap.Fields(zap.String("component", "test")))
+
+	// nothing is scheduled until entries are dropped
+	log.Info("repeated")
+	require.Zero(t, fakeClock.Timers())
+
+	// burst followed by silence is reported once the interval passes, the
+	// period starts with the first dropped entry, not with the logger
+	fakeClock.Advance(time.Hour)
+	log.Info("repeated")
+	log.Info("repeated")
+	require.NoError(t, fakeClock.WaitForTimers(ctx, 1))
+	fakeClock.Advance(59 * time.Second)
+	require.Zero(t, logs.FilterMessage(summaryMessage).Len())
+	fakeClock.Advance(time.Second)
+	require.Eventually(t, func() bool {
+		return logs.FilterMessage(summaryMessage).Len() == 1
+	}, time.Second, time.Millisecond)
+
+	summary := logs.FilterMessage(summaryMessage).All()[0]
+	require.EqualValues(t, 2, summary.ContextMap()["dropped"])
+	require.EqualValues(t, time.Minute, summary.ContextMap()["period"])
+	// summary is not related to the logger which dropped the entries
+	require.NotContains(t, summary.ContextMap(), "component")
+	require.Zero(t, fakeClock.Timers())
+
+	// Sync reports immediately and stops the timer, the period starts with
+	// the first entry dropped after the previous report
+	fakeClock.Advance(time.Hour)
+	log.Info("repeated")
+	require.NoError(t, fakeClock.WaitForTimers(ctx, 1))
+	fakeClock.Advance(10 * time.Second)
+	require.NoError(t, log.Sync())
+	require.Equal(t, 2, logs.FilterMessage(summaryMessage).Len())
+	require.EqualValues(t, 1, logs.FilterMessage(summaryMessage).All()[1].ContextMap()["dropped"])
+	require.EqualValues(t, 10*time.Second, logs.FilterMessage(summaryMessage).All()[1].ContextMap()["period"])
+	require.Zero(t, fakeClock.Timers())
+
+	// nothing is reported if no entries are dropped
+	fakeClock.Advance(time.Hour)
+	require.NoError(t, log.Sync())
+	require.Equal(t, 2, logs.FilterMessage(summaryMessage).Len())
+}
@@ -0,0 +1,41 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/clock"
+)
+
+func TestSampling(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := zap.New(newSamplingCore(core, SamplingConfig{
+		Initial:    2,
+		Thereafter: 3,
+		Interval:   time.Hour,
+	}, clock.Real()))
+
+	for i := 0; i < 10; i++ {
+		log.Info("repeated")
+		log.Warn("repeated")
+	}
+	log.Info("other")
+	require.NoError(t, log.Sync())
+
+	counts := map[string]int{}
+	for _, entry := range logs.FilterMessage("repeated").All() {
+		counts[entry.Level.String()]++
+	}
+	// entries 1, 2, 5 and 8 are written for each level
+	require.Equal(t, map[string]int{"info": 4, "warn": 4}, counts)
+	require.Equal(t, 1, logs.FilterMessage("other").Len())
+
+	summary := logs.FilterMessage("Log entries dropped by sampling").All()
+	require.Len(t, summary, 1)
+	require.EqualValues(t, 12, summary[0].ContextMap()["dropped"])
+}
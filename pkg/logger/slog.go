@@ -0,0 +1,288 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sort"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	_ slog.Handler   = &slogHandler{}
+	_ zapcore.Core   = &slogCore{}
+	_ slog.LogValuer = slogFields(nil)
+)
+
+// ********** slog -> zap **********
+
+// NewSlogHandler returns slog handler writing records to the logger taken from
+// the context passed to the handler, so level, name and fields of that logger
+// are honoured. The fallback logger is used if the context contains none, if it
+// is nil the default logger is used, see SetDefault.
+//
+// Use it to make code logging with log/slog write to the output configured by
+// New:
+//
+//	slog.SetDefault(slog.New(logger.NewSlogHandler(log)))
+func NewSlogHandler(fallback *zap.Logger) slog.Handler {
+	return &slogHandler{
+		fallback: fallback,
+	}
+}
+
+type slogHandler struct {
+	fallback *zap.Logger
+	fields   []zap.Field
+	groups   []string
+}
+
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.logger(ctx).Core().Enabled(zapLevel(level))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, record slog.Record) error {
+	ce := h.logger(ctx).Check(zapLevel(record.Level), record.Message)
+	if ce == nil {
+		return nil
+	}
+
+	if !record.Time.IsZero() {
+		ce.Time = record.Time
+	}
+	if record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		ce.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+		ce.Caller.Function = frame.Function
+	}
+
+	fields := h.fields[:len(h.fields):len(h.fields)]
+	if record.NumAttrs() > 0 {
+		fields = appendNamespaces(fields, h.groups)
+		record.Attrs(func(attr slog.Attr) bool {
+			fields = appendAttr(fields, attr)
+			return true
+		})
+	}
+	ce.Write(fields...)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.fields = appendNamespaces(h.fields[:len(h.fields):len(h.fields)], h.groups)
+	h2.groups = nil
+	for _, attr := range attrs {
+		h2.fields = appendAttr(h2.fields, attr)
+	}
+	return &h2
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	// namespace is opened only when attributes are added, so empty groups are omitted
+	h2.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+	return &h2
+}
+
+func (h *slogHandler) logger(ctx context.Context) *zap.Logger {
+	if log, ok := Lookup(ctx); ok {
+		return log
+	}
+	if h.fallback != nil {
+		return h.fallback
+	}
+	return Default()
+}
+
+func appendNamespaces(fields []zap.Field, groups []string) []zap.Field {
+	for _, group := range groups {
+		fields = append(fields, zap.Namespace(group))
+	}
+	return fields
+}
+
+func appendAttr(fields []zap.Field, attr slog.Attr) []zap.Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		return append(fields, zap.String(attr.Key, attr.Value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(attr.Key, attr.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(attr.Key, attr.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(attr.Key, attr.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(attr.Key, attr.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(attr.Key, attr.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(attr.Key, attr.Value.Time()))
+	case slog.KindGroup:
+		attrs := attr.Value.Group()
+		if len(attrs) == 0 {
+			return fields
+		}
+		if attr.Key == "" {
+			// attributes of the group with empty key are inlined
+			for _, a := range attrs {
+				fields = appendAttr(fields, a)
+			}
+			return fields
+		}
+		return append(fields, zap.Object(attr.Key, slogGroup(attrs)))
+	default:
+		if err, ok := attr.Value.Any().(error); ok {
+			return append(fields, zap.NamedError(attr.Key, err))
+		}
+		return append(fields, zap.Any(attr.Key, attr.Value.Any()))
+	}
+}
+
+type slogGroup []slog.Attr
+
+func (g slogGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	var fields []zap.Field
+	for _, attr := range g {
+		fields = appendAttr(fields, attr)
+	}
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	return nil
+}
+
+func zapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case level >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
+
+// ********** zap -> slog **********
+
+// NewSlogCore returns zap core writing entries to the slog handler. Use it to
+// make code logging with zap write to the output of an application configured
+// with log/slog:
+//
+//	log := zap.New(logger.NewSlogCore(slog.Default().Handler()))
+func NewSlogCore(handler slog.Handler) zapcore.Core {
+	return &slogCore{
+		handler: handler,
+	}
+}
+
+type slogCore struct {
+	handler slog.Handler
+}
+
+func (c *slogCore) Enabled(level zapcore.Level) bool {
+	return c.handler.Enabled(context.Background(), slogLevel(level))
+}
+
+func (c *slogCore) With(fields []zapcore.Field) zapcore.Core {
+	handler := c.handler
+	var attrs []slog.Attr
+	for _, field := range fields {
+		if field.Type == zapcore.NamespaceType {
+			handler = handler.WithAttrs(attrs).WithGroup(field.Key)
+			attrs = nil
+			continue
+		}
+		attrs = append(attrs, fieldAttrs(field)...)
+	}
+	return &slogCore{
+		handler: handler.WithAttrs(attrs),
+	}
+}
+
+func (c *slogCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *slogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	record := slog.NewRecord(entry.Time, slogLevel(entry.Level), entry.Message, entry.Caller.PC)
+	if entry.LoggerName != "" {
+		record.AddAttrs(slog.String(EncoderConfig.NameKey, entry.LoggerName))
+	}
+	record.AddAttrs(slogFields(fields).attrs()...)
+	if entry.Stack != "" {
+		record.AddAttrs(slog.String(EncoderConfig.StacktraceKey, entry.Stack))
+	}
+	return c.handler.Handle(context.Background(), record)
+}
+
+func (c *slogCore) Sync() error {
+	return nil
+}
+
+type slogFields []zapcore.Field
+
+func (f slogFields) LogValue() slog.Value {
+	return slog.GroupValue(f.attrs()...)
+}
+
+// attrs converts fields to attributes, fields following a namespace are nested in a group
+func (f slogFields) attrs() []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(f))
+	for i, field := range f {
+		if field.Type == zapcore.NamespaceType {
+			return append(attrs, slog.Any(field.Key, slogFields(f[i+1:])))
+		}
+		attrs = append(attrs, fieldAttrs(field)...)
+	}
+	return attrs
+}
+
+func fieldAttrs(field zapcore.Field) []slog.Attr {
+	enc := zapcore.NewMapObjectEncoder()
+	field.AddTo(enc)
+
+	// some fields, like errors, produce more than one key
+	keys := make([]string, 0, len(enc.Fields))
+	for key := range enc.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, slog.Any(key, enc.Fields[key]))
+	}
+	return attrs
+}
+
+func slogLevel(level zapcore.Level) slog.Level {
+	switch {
+	case level >= zapcore.ErrorLevel:
+		return slog.LevelError
+	case level >= zapcore.WarnLevel:
+		return slog.LevelWarn
+	case level >= zapcore.InfoLevel:
+		return slog.LevelInfo
+	default:
+		return slog.LevelDebug
+	}
+}
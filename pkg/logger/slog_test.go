@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlogHandler(t *testing.T) {
+	fallbackCore, fallbackLogs := observer.New(zapcore.InfoLevel)
+	ctxCore, ctxLogs := observer.New(zapcore.DebugLevel)
+
+	log := slog.New(NewSlogHandler(zap.New(fallbackCore)))
+	ctx := With(WithLogger(context.Background(), zap.New(ctxCore).Named("app")), zap.String("component", "updater"))
+
+	log.Debug("dropped by fallback level")
+	log.Info("fallback")
+	log.With("attr", 1).InfoContext(ctx, "context",
+		slog.String("string", "value"),
+		slog.Group("group", slog.Bool("bool", true)),
+		slog.Any("error", errors.New("oops")),
+	)
+	log.WithGroup("request").With("id", 7).WarnContext(ctx, "grouped", "status", 200)
+	log.WithGroup("empty").DebugContext(ctx, "empty group")
+
+	require.Equal(t, 1, fallbackLogs.Len())
+	require.Equal(t, "fallback", fallbackLogs.All()[0].Message)
+
+	entries := ctxLogs.All()
+	require.Len(t, entries, 3)
+
+	require.Equal(t, "context", entries[0].Message)
+	require.Equal(t, zapcore.InfoLevel, entries[0].Level)
+	require.Equal(t, "app", entries[0].LoggerName)
+	require.Contains(t, entries[0].Caller.File, "slog_test.go")
+	require.Equal(t, map[string]interface{}{
+		"component": "updater",
+		"attr":      int64(1),
+		"string":    "value",
+		"group":     map[string]interface{}{"bool": true},
+		"error":     "oops",
+	}, entries[0].ContextMap())
+
+	require.Equal(t, zapcore.WarnLevel, entries[1].Level)
+	require.Equal(t, map[string]interface{}{
+		"component": "updater",
+		"request":   map[string]interface{}{"id": int64(7), "status": int64(200)},
+	}, entries[1].ContextMap())
+
+	require.Equal(t, zapcore.DebugLevel, entries[2].Level)
+	require.Equal(t, map[string]interface{}{"component": "updater"}, entries[2].ContextMap())
+}
+
+func TestSlogCore(t *testing.T) {
+	buf := &bytes.Buffer{}
+	handler := slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo})
+	log := zap.New(NewSlogCore(handler)).Named("app").With(zap.String("component", "updater"))
+
+	log.Debug("dropped")
+	log.Info("message", zap.Int("count", 3), zap.Namespace("ns"), zap.String("key", "value"))
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	delete(record, "time")
+	require.Equal(t, map[string]interface{}{
+		"level":     "INFO",
+		"msg":       "message",
+		"logger":    "app",
+		"component": "updater",
+		"count":     float64(3),
+		"ns":        map[string]interface{}{"key": "value"},
+	}, record)
+}
@@ -0,0 +1,173 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	envPrefix  = "LOG_"
+	flagPrefix = "log-"
+
+	// ConfigSection is the section of the config file containing logger settings
+	ConfigSection = "log"
+)
+
+// EnvName returns the name of environment variable setting the logger flag,
+// e.g. LOG_FORMAT for --log-format and LOG_VERBOSE for --verbose
+func EnvName(flag string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(configKey(flag), "-", "_"))
+}
+
+func (s Sources) envName(flag string) string {
+	if s.EnvPrefix == "" {
+		return EnvName(flag)
+	}
+	return s.EnvPrefix + "_" + EnvName(flag)
+}
+
+// configKey returns the key of the config file section setting the logger flag,
+// e.g. format for --log-format
+func configKey(flag string) string {
+	return strings.TrimPrefix(flag, flagPrefix)
+}
+
+// applySources sets the flags not passed on the command line to the values of
+// environment variables or, if they are not set, the config file
+func applySources(flags *pflag.FlagSet, sources Sources) error {
+	cli := map[string]bool{}
+	flags.Visit(func(f *pflag.Flag) {
+		cli[f.Name] = true
+	})
+	path, err := flags.GetString("log-config")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !cli["log-config"] {
+		if envPath, ok := sources.LookupEnv(sources.envName("log-config")); ok {
+			path = envPath
+		} else {
+			path = sources.ConfigFile
+		}
+	}
+
+	fileValues := map[string]interface{}{}
+	if path != "" {
+		if fileValues, err = readConfigFile(path); err != nil {
+			return err
+		}
+	}
+
+	// the flag set might contain flags of the application too
+	loggerFlags := Flags(Config{}, "logger")
+	fileFlags := map[string]*pflag.Flag{}
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Name != "log-config" && loggerFlags.Lookup(f.Name) != nil {
+			fileFlags[configKey(f.Name)] = f
+		}
+	})
+	for key := range fileValues {
+		if fileFlags[key] == nil {
+			return errors.Errorf("unknown logger setting %q in config file", key)
+		}
+	}
+
+	for key, f := range fileFlags {
+		if cli[f.Name] {
+			continue
+		}
+		if value, ok := sources.LookupEnv(sources.envName(f.Name)); ok {
+			if err := setFlag(flags, f, splitEnv(f, value)); err != nil {
+				return errors.Wrapf(err, "invalid value of %s environment variable", sources.envName(f.Name))
+			}
+			continue
+		}
+		if value, ok := fileValues[key]; ok {
+			values, err := configValues(value)
+			if err != nil {
+				return errors.Wrapf(err, "invalid value of %q in config file", key)
+			}
+			if err := setFlag(flags, f, values); err != nil {
+				return errors.Wrapf(err, "invalid value of %q in config file", key)
+			}
+		}
+	}
+	return nil
+}
+
+// readConfigFile returns the logger section of the YAML or JSON config file
+func readConfigFile(path string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// the file might be shared with the application, so only the logger
+	// section is expected to be an object
+	var config map[string]interface{}
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return nil, errors.Wrapf(err, "parsing config file %s failed", path)
+	}
+	section, ok := config[ConfigSection]
+	if !ok || section == nil {
+		return nil, nil
+	}
+	values, ok := section.(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("%s section of config file %s must be an object", ConfigSection, path)
+	}
+	return values, nil
+}
+
+// splitEnv splits the value of environment variable setting the flag which
+// might be repeated
+func splitEnv(f *pflag.Flag, value string) []string {
+	if f.Value.Type() == "stringArray" {
+		return strings.Split(value, ",")
+	}
+	return []string{value}
+}
+
+// configValues converts the value taken from the config file to the flag values,
+// lists set the flag many times, maps are converted to key=value lists
+func configValues(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			itemValues, err := configValues(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, itemValues...)
+		}
+		return values, nil
+	case map[string]interface{}:
+		pairs := make([]string, 0, len(v))
+		for key, item := range v {
+			pairs = append(pairs, fmt.Sprintf("%s=%v", key, item))
+		}
+		sort.Strings(pairs)
+		return []string{strings.Join(pairs, ",")}, nil
+	case nil:
+		return nil, nil
+	case string, bool, int, float64:
+		return []string{fmt.Sprint(v)}, nil
+	default:
+		return nil, errors.Errorf("unsupported value %v", v)
+	}
+}
+
+func setFlag(flags *pflag.FlagSet, f *pflag.Flag, values []string) error {
+	for _, value := range values {
+		if err := flags.Set(f.Name, value); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func testEnv(env map[string]string) func(key string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestConfigurePrecedence(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+app:
+  port: 8080
+log:
+  format: yaml
+  level: warn
+  color: always
+  levels:
+    parallel: error
+    app.updater: debug
+  output:
+    - stderr
+    - /var/log/app.log?format=json
+  sampling-initial: 100
+  sampling-thereafter: 10
+  sampling-interval: 5s
+  redact: ["*token*"]
+`)
+
+	// file only
+	config, err := configure(ToolDefaultConfig, []string{"--log-config", path}, testEnv(nil))
+	require.NoError(t, err)
+	require.Equal(t, FormatYAML, config.Format)
+	require.Equal(t, zapcore.WarnLevel, config.Level)
+	require.Equal(t, ColorAlways, config.Color)
+	require.Equal(t, map[string]zapcore.Level{"parallel": zapcore.ErrorLevel, "app.updater": zapcore.DebugLevel}, config.LevelOverrides)
+	require.Equal(t, []Output{
+		{Path: OutputStderr, Level: zapcore.DebugLevel},
+		{Path: "/var/log/app.log", Format: FormatJSON, Level: zapcore.DebugLevel},
+	}, config.Outputs)
+	require.Equal(t, SamplingConfig{Initial: 100, Thereafter: 10, Interval: 5 * time.Second}, config.Sampling)
+	require.Equal(t, []string{"*token*"}, config.Redact)
+
+	// env overrides file
+	config, err = configure(ToolDefaultConfig, nil, testEnv(map[string]string{
+		"LOG_CONFIG":           path,
+		"LOG_FORMAT":           "json",
+		"LOG_LEVEL":            "error",
+		"LOG_OUTPUT":           "stdout,app.log?level=debug",
+		"LOG_REDACT":           "*secret*,*key*",
+		"LOG_VERBOSE":          "true",
+		"LOG_SAMPLING_INITIAL": "5",
+	}))
+	require.NoError(t, err)
+	require.Equal(t, FormatJSON, config.Format)
+	require.Equal(t, zapcore.ErrorLevel, config.Level)
+	require.True(t, config.Verbose)
+	require.Equal(t, []Output{{Path: OutputStdout, Level: zapcore.DebugLevel}, {Path: "app.log", Level: zapcore.DebugLevel}}, config.Outputs)
+	require.Equal(t, []string{"*secret*", "*key*"}, config.Redact)
+	require.Equal(t, 5, config.Sampling.Initial)
+	require.Equal(t, 10, config.Sampling.Thereafter)
+
+	// flags override env
+	config, err = configure(ToolDefaultConfig, []string{"--log-format=console", "--log-level", "info", "--unknown"},
+		testEnv(map[string]string{
+			"LOG_CONFIG": path,
+			"LOG_FORMAT": "json",
+			"LOG_LEVEL":  "error",
+		}))
+	require.NoError(t, err)
+	require.Equal(t, FormatConsole, config.Format)
+	require.Equal(t, zapcore.InfoLevel, config.Level)
+	require.Equal(t, ColorAlways, config.Color)
+
+	// defaults
+	config, err = configure(ServiceDefaultConfig, nil, testEnv(nil))
+	require.NoError(t, err)
+	require.Equal(t, FormatJSON, config.Format)
+	require.Equal(t, zapcore.DebugLevel, config.Level)
+	require.False(t, config.Verbose)
+	require.Equal(t, DefaultRedactPatterns, config.Redact)
+	require.Empty(t, config.Outputs)
+}
+
+func TestConfigureJSONFile(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `{"log": {"format": "logfmt", "verbose": true, "levels": "parallel=warn"}}`)
+
+	config, err := configure(ToolDefaultConfig, []string{"--log-config=" + path}, testEnv(nil))
+	require.NoError(t, err)
+	require.Equal(t, FormatLogfmt, config.Format)
+	require.True(t, config.Verbose)
+	require.Equal(t, map[string]zapcore.Level{"parallel": zapcore.WarnLevel}, config.LevelOverrides)
+
+	// file without logger section
+	path = writeConfigFile(t, "other.json", `{"app": {"port": 8080}}`)
+	config, err = configure(ToolDefaultConfig, []string{"--log-config=" + path}, testEnv(nil))
+	require.NoError(t, err)
+	require.Equal(t, FormatConsole, config.Format)
+}
+
+func TestConfigureSharedFile(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+listen: ":9090"
+peers: ["peer1:26656", "peer2:26656"]
+verbose: false
+db:
+  host: db.local
+log:
+  format: json
+  level: warn
+`)
+
+	config, err := configure(ToolDefaultConfig, []string{"--log-config", path}, testEnv(nil))
+	require.NoError(t, err)
+	require.Equal(t, FormatJSON, config.Format)
+	require.Equal(t, zapcore.WarnLevel, config.Level)
+}
+
+func TestConfigureErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown": "log:\n  formatt: json\n",
+		"invalid": "log:\n  sampling-initial: many\n",
+		"format":  "log:\n  format: xml\n",
+		"level":   "log:\n  level: loud\n",
+		"syntax":  "log: [",
+		"section": "log: json\n",
+	} {
+		path := writeConfigFile(t, name+".yaml", content)
+		_, err := configure(ToolDefaultConfig, []string{"--log-config", path}, testEnv(nil))
+		require.Error(t, err, name)
+	}
+
+	_, err := configure(ToolDefaultConfig, nil, testEnv(map[string]string{"LOG_VERBOSE": "maybe"}))
+	require.Error(t, err)
+
+	_, err = configure(ToolDefaultConfig, []string{"--log-config", "missing.yaml"}, testEnv(nil))
+	require.Error(t, err)
+}
@@ -0,0 +1,215 @@
+package parallel
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// cronSearchYears limits how far into the future the next activation is searched for
+const cronSearchYears = 5
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	cronMinute = cronField{name: "minute", min: 0, max: 59}
+	cronHour   = cronField{name: "hour", min: 0, max: 23}
+	cronDom    = cronField{name: "day of month", min: 1, max: 31}
+	cronMonth  = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is accepted as sunday, it is folded to 0 after parsing
+	cronDow = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// CronSchedule is a schedule defined by a standard 5-field cron expression.
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+// ParseCron parses the standard 5-field cron expression:
+//
+//	minute hour day-of-month month day-of-week
+//
+// Each field is a comma-separated list of values (5), ranges (1-5), wildcards
+// (*) and steps (*/15, 1-30/2, 10/5). Months and days of week may be given by
+// their three-letter english names (jan, mon), sunday is either 0 or 7.
+// If both day of month and day of week are restricted, the schedule activates
+// when either of them matches.
+//
+// Macros @yearly (@annually), @monthly, @weekly, @daily (@midnight) and
+// @hourly are supported as well.
+func ParseCron(spec string) (CronSchedule, error) {
+	expr := strings.TrimSpace(spec)
+	if macro, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return CronSchedule{}, errors.Errorf("cron expression %q must contain 5 fields, got %d", spec, len(fields))
+	}
+
+	var s CronSchedule
+	var err error
+	if s.minute, err = parseCronField(fields[0], cronMinute); err != nil {
+		return CronSchedule{}, errors.Wrapf(err, "invalid cron expression %q", spec)
+	}
+	if s.hour, err = parseCronField(fields[1], cronHour); err != nil {
+		return CronSchedule{}, errors.Wrapf(err, "invalid cron expression %q", spec)
+	}
+	if s.dom, err = parseCronField(fields[2], cronDom); err != nil {
+		return CronSchedule{}, errors.Wrapf(err, "invalid cron expression %q", spec)
+	}
+	if s.month, err = parseCronField(fields[3], cronMonth); err != nil {
+		return CronSchedule{}, errors.Wrapf(err, "invalid cron expression %q", spec)
+	}
+	if s.dow, err = parseCronField(fields[4], cronDow); err != nil {
+		return CronSchedule{}, errors.Wrapf(err, "invalid cron expression %q", spec)
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domStar = strings.HasPrefix(fields[2], "*")
+	s.dowStar = strings.HasPrefix(fields[4], "*")
+	return s, nil
+}
+
+// Next returns the first activation time strictly after t, in the location of t.
+//
+// Activations are matched against the wall clock of the location, so the ones
+// falling into the hour skipped when daylight saving time starts don't happen,
+// while the ones in the hour repeated when it ends happen twice, once for each
+// offset.
+func (s CronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	// truncation is done on absolute time, so it doesn't move the repeated hour
+	// to its first occurrence, as rebuilding the time from the wall clock would
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Year() + cronSearchYears
+
+	for t.Year() <= limit {
+		switch {
+		case !bitSet(s.month, int(t.Month())):
+			t = cronAdvance(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc))
+		case !s.dayMatches(t):
+			t = cronAdvance(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+		case !bitSet(s.hour, t.Hour()):
+			t = cronAdvance(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc))
+		case !bitSet(s.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// cronAdvance returns next, the start of the following month, day or hour,
+// unless its wall clock doesn't exist because the clocks are moved forward at
+// that time. Then time.Date might normalize it backwards, to t or even before,
+// so the end of the skipped interval is returned to keep moving forward.
+func cronAdvance(t, next time.Time) time.Time {
+	if next.After(t) {
+		return next
+	}
+	if _, end := next.ZoneBounds(); end.After(t) {
+		return end
+	}
+	return t.Add(time.Minute)
+}
+
+func (s CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := bitSet(s.dom, t.Day())
+	dowMatch := bitSet(s.dow, int(t.Weekday()))
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func parseCronField(field string, spec cronField) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(field, ",") {
+		rangeExpr, stepExpr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepExpr)
+			if err != nil || step <= 0 {
+				return 0, errors.Errorf("invalid step %q in %s field", stepExpr, spec.name)
+			}
+		}
+
+		var from, to int
+		switch {
+		case rangeExpr == "*":
+			from, to = spec.min, spec.max
+		case strings.Contains(rangeExpr, "-"):
+			fromExpr, toExpr, _ := strings.Cut(rangeExpr, "-")
+			var err error
+			if from, err = parseCronValue(fromExpr, spec); err != nil {
+				return 0, err
+			}
+			if to, err = parseCronValue(toExpr, spec); err != nil {
+				return 0, err
+			}
+			if from > to {
+				return 0, errors.Errorf("invalid range %q in %s field", rangeExpr, spec.name)
+			}
+		default:
+			var err error
+			if from, err = parseCronValue(rangeExpr, spec); err != nil {
+				return 0, err
+			}
+			to = from
+			if hasStep {
+				to = spec.max
+			}
+		}
+
+		for v := from; v <= to; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func parseCronValue(value string, spec cronField) (int, error) {
+	if v, ok := spec.names[strings.ToLower(value)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.Errorf("invalid value %q in %s field", value, spec.name)
+	}
+	if v < spec.min || v > spec.max {
+		return 0, errors.Errorf("value %d out of range [%d, %d] in %s field", v, spec.min, spec.max, spec.name)
+	}
+	return v, nil
+}
+
+func bitSet(bits uint64, v int) bool {
+	return bits&(1<<v) != 0
+}
@@ -0,0 +1,202 @@
+package parallel
+
+import (
+	"testing"
+	"time"
+	// time zones used by DST tests must be available everywhere
+	_ "time/tzdata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronNext(t *testing.T) {
+	start := time.Date(2024, time.January, 31, 10, 17, 42, 0, time.UTC)
+	tests := []struct {
+		spec string
+		next []time.Time
+	}{
+		{
+			spec: "* * * * *",
+			next: []time.Time{
+				time.Date(2024, time.January, 31, 10, 18, 0, 0, time.UTC),
+				time.Date(2024, time.January, 31, 10, 19, 0, 0, time.UTC),
+			},
+		},
+		{
+			spec: "*/15 * * * *",
+			next: []time.Time{
+				time.Date(2024, time.January, 31, 10, 30, 0, 0, time.UTC),
+				time.Date(2024, time.January, 31, 10, 45, 0, 0, time.UTC),
+				time.Date(2024, time.January, 31, 11, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			spec: "0 9-17/4 * * mon-fri",
+			next: []time.Time{
+				time.Date(2024, time.January, 31, 13, 0, 0, 0, time.UTC),
+				time.Date(2024, time.January, 31, 17, 0, 0, 0, time.UTC),
+				time.Date(2024, time.February, 1, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			spec: "30 0 29 feb *",
+			next: []time.Time{
+				time.Date(2024, time.February, 29, 0, 30, 0, 0, time.UTC),
+				time.Date(2028, time.February, 29, 0, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			// day of month and day of week are joined with OR
+			spec: "0 0 1 * 7",
+			next: []time.Time{
+				time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.February, 4, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.February, 11, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			spec: "@monthly",
+			next: []time.Time{
+				time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			spec: "5,10 23 31 12 *",
+			next: []time.Time{
+				time.Date(2024, time.December, 31, 23, 5, 0, 0, time.UTC),
+				time.Date(2024, time.December, 31, 23, 10, 0, 0, time.UTC),
+				time.Date(2025, time.December, 31, 23, 5, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.spec, func(t *testing.T) {
+			s, err := ParseCron(tc.spec)
+			require.NoError(t, err)
+			next := start
+			for _, expected := range tc.next {
+				next = s.Next(next)
+				require.Equal(t, expected, next)
+			}
+		})
+	}
+}
+
+func TestCronNextDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	santiago, err := time.LoadLocation("America/Santiago")
+	require.NoError(t, err)
+
+	est := time.FixedZone("EST", -5*3600)
+	edt := time.FixedZone("EDT", -4*3600)
+	clt := time.FixedZone("CLT", -4*3600)
+	clst := time.FixedZone("CLST", -3*3600)
+
+	tests := []struct {
+		name  string
+		spec  string
+		start time.Time
+		next  []time.Time
+	}{
+		{
+			// 02:00-03:00 is skipped on March 8
+			name:  "spring forward, skipped hour",
+			spec:  "30 2 * * *",
+			start: time.Date(2026, time.March, 7, 23, 0, 0, 0, newYork),
+			next: []time.Time{
+				time.Date(2026, time.March, 9, 2, 30, 0, 0, edt),
+			},
+		},
+		{
+			name:  "spring forward, every 30 minutes",
+			spec:  "*/30 * * * *",
+			start: time.Date(2026, time.March, 8, 1, 0, 0, 0, newYork),
+			next: []time.Time{
+				time.Date(2026, time.March, 8, 1, 30, 0, 0, est),
+				time.Date(2026, time.March, 8, 3, 0, 0, 0, edt),
+				time.Date(2026, time.March, 8, 3, 30, 0, 0, edt),
+			},
+		},
+		{
+			// midnight is skipped on September 6
+			name:  "spring forward, skipped midnight",
+			spec:  "0 0 * * *",
+			start: time.Date(2026, time.September, 5, 12, 0, 0, 0, santiago),
+			next: []time.Time{
+				time.Date(2026, time.September, 7, 0, 0, 0, 0, clst),
+			},
+		},
+		{
+			name:  "spring forward, skipped midnight, every hour",
+			spec:  "0 * * * *",
+			start: time.Date(2026, time.September, 5, 22, 30, 0, 0, santiago),
+			next: []time.Time{
+				time.Date(2026, time.September, 5, 23, 0, 0, 0, clt),
+				time.Date(2026, time.September, 6, 1, 0, 0, 0, clst),
+			},
+		},
+		{
+			// 01:00-02:00 is repeated on November 1
+			name:  "fall back, repeated hour",
+			spec:  "30 1 * * *",
+			start: time.Date(2026, time.October, 31, 23, 0, 0, 0, newYork),
+			next: []time.Time{
+				time.Date(2026, time.November, 1, 1, 30, 0, 0, edt),
+				time.Date(2026, time.November, 1, 1, 30, 0, 0, est),
+				time.Date(2026, time.November, 2, 1, 30, 0, 0, est),
+			},
+		},
+		{
+			name:  "fall back, every hour",
+			spec:  "0 * * * *",
+			start: time.Date(2026, time.November, 1, 0, 30, 0, 0, newYork),
+			next: []time.Time{
+				time.Date(2026, time.November, 1, 1, 0, 0, 0, edt),
+				time.Date(2026, time.November, 1, 1, 0, 0, 0, est),
+				time.Date(2026, time.November, 1, 2, 0, 0, 0, est),
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := ParseCron(tc.spec)
+			require.NoError(t, err)
+			next := tc.start
+			for _, expected := range tc.next {
+				next = s.Next(next)
+				require.True(t, expected.Equal(next), "expected %s, got %s", expected, next)
+				require.Equal(t, tc.start.Location(), next.Location())
+			}
+		})
+	}
+}
+
+func TestCronNextNever(t *testing.T) {
+	s, err := ParseCron("0 0 30 feb *")
+	require.NoError(t, err)
+	require.True(t, s.Next(time.Now()).IsZero())
+}
+
+func TestCronParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@often",
+	} {
+		_, err := ParseCron(spec)
+		require.Error(t, err, spec)
+	}
+}
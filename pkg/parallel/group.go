@@ -2,24 +2,81 @@ package parallel
 
 import (
 	"context"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
+	"github.com/CoreumFoundation/coreum-tools/pkg/clock"
 	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+	"github.com/CoreumFoundation/coreum-tools/pkg/trace"
 )
 
 var nextTaskID int64 = 0x0bace1d000000000
 
+// ErrShutdownTimeout is returned by Wait if subtasks do not finish within the
+// shutdown timeout, see WithShutdownTimeout.
+var ErrShutdownTimeout = errors.New("shutdown timeout exceeded")
+
 // GroupOption is group option.
 type GroupOption func(o *Group)
 
 // WithGroupLogger is group option which sets the custom logger for the group.
+// If the logger doesn't implement LevelLogger, info messages are logged at debug
+// level and warnings at error level.
 func WithGroupLogger(log Logger) GroupOption {
 	return func(o *Group) {
-		o.log = log
+		o.log = levelLogger(log)
+	}
+}
+
+// WithPanicPolicy is group option which sets the way panics in subtasks are
+// handled. Default is PanicFail.
+func WithPanicPolicy(policy PanicPolicy) GroupOption {
+	return func(o *Group) {
+		o.panicPolicy = policy
+	}
+}
+
+// WithPanicRestartBackoff is group option which sets the delays between
+// restarts of subtasks panicking with PanicRestart policy. The first restart is
+// delayed by initial, each next one twice as long, up to max. Delays are reset
+// once the subtask runs for max without panicking. Default is 100ms and 1 minute.
+func WithPanicRestartBackoff(initial, max time.Duration) GroupOption {
+	return func(o *Group) {
+		o.restartBackoff = initial
+		o.maxRestartBackoff = max
+	}
+}
+
+// WithMaxPanicRestarts is group option which limits the number of consecutive
+// restarts of a subtask panicking with PanicRestart policy. Once the limit is
+// exceeded, the panic is handled as with PanicFail. Default 0 means no limit.
+func WithMaxPanicRestarts(maxRestarts int) GroupOption {
+	return func(o *Group) {
+		o.maxRestarts = maxRestarts
+	}
+}
+
+// WithPanicHook is group option which sets the function called for each panic
+// in a subtask.
+func WithPanicHook(hook PanicHook) GroupOption {
+	return func(o *Group) {
+		o.panicHook = hook
+	}
+}
+
+// WithShutdownTimeout is group option which limits the time the group waits
+// for its subtasks to finish once it starts shutting down. When the timeout
+// expires, Wait returns without waiting for the remaining subtasks, which are
+// left running. Time is measured by the clock taken from the group context.
+func WithShutdownTimeout(timeout time.Duration) GroupOption {
+	return func(o *Group) {
+		o.shutdownTimeout = timeout
 	}
 }
 
@@ -42,34 +99,39 @@ type Group struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 
-	log Logger
+	log               LevelLogger
+	panicPolicy       PanicPolicy
+	panicHook         PanicHook
+	restartBackoff    time.Duration
+	maxRestartBackoff time.Duration
+	maxRestarts       int
+	shutdownTimeout   time.Duration
 
-	mu      sync.Mutex
-	running int
-	done    chan struct{}
-	closing bool
-	err     error
+	mu        sync.Mutex
+	running   int
+	tasks     map[int64]string
+	done      chan struct{}
+	abandoned chan struct{}
+	closing   bool
+	err       error
 }
 
 // NewGroup creates a new Group controlled by the given context
 func NewGroup(ctx context.Context, options ...GroupOption) *Group {
 	g := new(Group)
 
-	var log Logger
-	zapLog := logger.Get(ctx)
-	if zapLog != nil {
-		log = NewZapLogger(zapLog)
-	} else {
-		log = NewNoOpLogger()
-	}
-	g.log = log
+	g.log = NewContextLogger()
+	g.restartBackoff = 100 * time.Millisecond
+	g.maxRestartBackoff = time.Minute
 	for _, o := range options {
 		o(g)
 	}
 
 	g.ctx, g.cancel = context.WithCancel(ctx)
+	g.tasks = map[int64]string{}
 	g.done = make(chan struct{})
 	close(g.done)
+	g.abandoned = make(chan struct{})
 	return g
 }
 
@@ -119,31 +181,80 @@ func (g *Group) Context() context.Context {
 // When a subtask finishes, it sets the result of the group if it's not already
 // set (unless the task returns nil and its OnExit mode is Continue).
 func (g *Group) Spawn(name string, onExit OnExit, task Task) {
+	id := atomic.AddInt64(&nextTaskID, 1)
+
 	g.mu.Lock()
 	if g.running == 0 {
 		g.done = make(chan struct{})
 	}
 	g.running++
+	g.tasks[id] = name
 	g.mu.Unlock()
 
-	id := atomic.AddInt64(&nextTaskID, 1)
+	// each task of the traced group runs in its own span
+	ctx, span := trace.StartChild(g.ctx, name)
+	ctx = logger.WithTrace(ctx)
+	span.SetAttribute("id", id)
+	span.SetAttribute("onExit", onExit.String())
+
 	g.log.Debug(
-		g.ctx,
+		ctx,
 		"Task spawned",
 		zap.String("name", name),
 		zap.Int64("id", id),
 		zap.String("onExit", onExit.String()),
 	)
 
-	go g.runTask(g.ctx, name, id, onExit, task)
+	go g.runTask(ctx, name, id, onExit, task, span)
 }
 
 // Second parameter is the task ID. It is ignored because the only reason to
 // pass it is to add it to the stack trace
-func (g *Group) runTask(ctx context.Context, name string, id int64, onExit OnExit, task Task) {
-	err := runTaskWithRecovery(ctx, g.log, name, id, onExit, task)
+func (g *Group) runTask(ctx context.Context, name string, id int64, onExit OnExit, task Task, span *trace.Span) {
+	var err error
+	clk := clock.Get(ctx)
+	delay := g.restartBackoff
+	var restarts int
+	for {
+		started := clk.Now()
+		var panicked bool
+		panicked, err = runTaskWithRecovery(ctx, g.log, g.panicPolicy, g.panicHook, name, id, onExit, task)
+		if !panicked || g.panicPolicy != PanicRestart || ctx.Err() != nil {
+			break
+		}
+		// task which has been running for a while is not panicking in a loop
+		if clk.Now().Sub(started) >= g.maxRestartBackoff {
+			delay = g.restartBackoff
+			restarts = 0
+		}
+		if g.maxRestarts > 0 && restarts >= g.maxRestarts {
+			g.log.Error(
+				ctx,
+				"Task panicked too many times, not restarting",
+				zap.String("name", name),
+				zap.Int64("id", id),
+				zap.String("onExit", onExit.String()),
+				zap.Int("restarts", restarts),
+			)
+			break
+		}
+		restarts++
+		g.log.Warn(
+			ctx,
+			"Restarting task after panic",
+			zap.String("name", name),
+			zap.Int64("id", id),
+			zap.String("onExit", onExit.String()),
+			zap.Int("restart", restarts),
+			zap.Duration("delay", delay),
+		)
+		if clock.Sleep(ctx, delay) != nil {
+			break
+		}
+		delay = min(2*delay, g.maxRestartBackoff)
+	}
 	if err != nil {
-		g.log.Debug(
+		g.log.Error(
 			ctx,
 			"Task finished with error",
 			zap.String("name", name),
@@ -160,6 +271,7 @@ func (g *Group) runTask(ctx context.Context, name string, id int64, onExit OnExi
 			zap.String("onExit", onExit.String()),
 		)
 	}
+	span.End(err)
 
 	g.mu.Lock()
 	defer g.mu.Unlock()
@@ -179,6 +291,7 @@ func (g *Group) runTask(ctx context.Context, name string, id int64, onExit OnExi
 	}
 
 	g.running--
+	delete(g.tasks, id)
 	if g.running == 0 {
 		close(g.done)
 	}
@@ -195,7 +308,39 @@ func (g *Group) exit(err error) {
 	if !g.closing {
 		g.closing = true
 		g.cancel()
+		if g.shutdownTimeout > 0 {
+			go g.watchShutdown(g.done)
+		}
+	}
+}
+
+func (g *Group) watchShutdown(done <-chan struct{}) {
+	timer := clock.Get(g.ctx).NewTimer(g.shutdownTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return
+	case <-timer.C():
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.running == 0 {
+		return
+	}
+	tasks := g.runningTasks()
+	g.log.Error(
+		g.ctx,
+		"Shutdown timeout exceeded",
+		zap.Duration("timeout", g.shutdownTimeout),
+		zap.Strings("tasks", tasks),
+	)
+	if g.err == nil {
+		g.err = errors.Wrapf(ErrShutdownTimeout, "tasks still running: %s", strings.Join(tasks, ", "))
 	}
+	close(g.abandoned)
 }
 
 // Exit prompts the group to shut down, if it's not already shutting down or
@@ -219,6 +364,29 @@ func (g *Group) Running() int {
 	return g.running
 }
 
+// RunningTasks returns the names of running subtasks in the order they were
+// spawned
+func (g *Group) RunningTasks() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.runningTasks()
+}
+
+func (g *Group) runningTasks() []string {
+	ids := make([]int64, 0, len(g.tasks))
+	for id := range g.tasks {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		names = append(names, g.tasks[id])
+	}
+	return names
+}
+
 // Done returns a channel that closes when the last running subtask finishes. If
 // no subtasks are running, the returned channel is already closed.
 func (g *Group) Done() <-chan struct{} {
@@ -232,8 +400,17 @@ func (g *Group) Done() <-chan struct{} {
 //
 // The group result is set by finishing subtasks (see the documentation for
 // OnExit modes) as well as by Exit calls.
+//
+// If the group was created with WithShutdownTimeout, Wait returns as soon as
+// the timeout expires, even if some subtasks are still running.
 func (g *Group) Wait() error {
-	<-g.Done()
+	select {
+	case <-g.Done():
+	case <-g.abandoned:
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
 
 	return g.err
 }
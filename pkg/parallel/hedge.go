@@ -0,0 +1,114 @@
+package parallel
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/clock"
+)
+
+// ResultTask is a task producing a result. See documentation for Task.
+type ResultTask[T any] func(ctx context.Context) (T, error)
+
+// FirstSuccess runs all the tasks concurrently and returns the result of the
+// first one which succeeds. As soon as it happens, the contexts of the
+// remaining tasks are closed and FirstSuccess waits for them to exit.
+//
+// If all the tasks fail, the returned error combines all of their errors. If
+// ctx is closed before any task succeeds, ctx.Err() is returned.
+//
+// Tasks are run as subtasks of a Group, so a panic in any of them is not
+// treated as a failed attempt, it is returned as ErrPanic instead.
+//
+// Example:
+//
+//	height, err := parallel.FirstSuccess(ctx, node1.Height, node2.Height, node3.Height)
+func FirstSuccess[T any](ctx context.Context, tasks ...ResultTask[T]) (T, error) {
+	return Hedged(ctx, 0, tasks...)
+}
+
+// Hedged runs the tasks one by one, starting the next one only if none of the
+// already started ones has succeeded within delay or if any of them has
+// failed. The result of the first successful task is returned, the remaining
+// tasks are then canceled. Zero delay starts all the tasks at once, which is
+// equivalent to FirstSuccess.
+//
+// Error semantics are the same as for FirstSuccess. The delay is measured by
+// the clock taken from the context, see package clock.
+//
+// Example:
+//
+//	block, err := parallel.Hedged(ctx, 200*time.Millisecond, primary.Block, backup.Block)
+func Hedged[T any](ctx context.Context, delay time.Duration, tasks ...ResultTask[T]) (T, error) {
+	var zero T
+	if len(tasks) == 0 {
+		return zero, errors.New("no tasks to run")
+	}
+
+	var mu sync.Mutex
+	var result T
+	var succeeded bool
+	errs := make([]error, len(tasks))
+	failed := make(chan struct{}, len(tasks))
+
+	g := NewGroup(ctx)
+	g.Spawn("hedge", Continue, func(ctx context.Context) error {
+		for i, task := range tasks {
+			g.Spawn(fmt.Sprintf("attempt-%d", i), Continue, func(ctx context.Context) error {
+				res, err := task(ctx)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				if err != nil {
+					errs[i] = err
+					failed <- struct{}{}
+					return nil
+				}
+				if !succeeded {
+					succeeded = true
+					result = res
+					g.Exit(nil)
+				}
+				return nil
+			})
+
+			if delay <= 0 || i == len(tasks)-1 {
+				continue
+			}
+			if err := waitForHedge(ctx, delay, failed); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err := g.Wait(); err != nil {
+		return zero, err
+	}
+	if succeeded {
+		return result, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return zero, errors.WithStack(err)
+	}
+	return zero, errors.Wrap(stderrors.Join(errs...), "all attempts failed")
+}
+
+func waitForHedge(ctx context.Context, delay time.Duration, failed <-chan struct{}) error {
+	timer := clock.Get(ctx).NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C():
+	case <-failed:
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package parallel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/parallel"
+	"github.com/CoreumFoundation/coreum-tools/pkg/parallel/paralleltest"
+)
+
+func TestFirstSuccess(t *testing.T) {
+	slowCanceled := make(chan struct{})
+	res, err := parallel.FirstSuccess(t.Context(),
+		func(ctx context.Context) (int, error) {
+			return 0, errors.New("oops")
+		},
+		func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			close(slowCanceled)
+			return 0, ctx.Err()
+		},
+		func(ctx context.Context) (int, error) {
+			return 3, nil
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, 3, res)
+	<-slowCanceled
+}
+
+func TestFirstSuccessAllFailed(t *testing.T) {
+	_, err := parallel.FirstSuccess(t.Context(),
+		func(ctx context.Context) (int, error) {
+			return 0, errors.New("oops1")
+		},
+		func(ctx context.Context) (int, error) {
+			return 0, errors.New("oops2")
+		},
+	)
+	require.EqualError(t, err, "all attempts failed: oops1\noops2")
+}
+
+func TestFirstSuccessPanic(t *testing.T) {
+	var panicErr parallel.ErrPanic
+	_, err := parallel.FirstSuccess(t.Context(),
+		func(ctx context.Context) (int, error) {
+			panic("oops")
+		},
+		func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		},
+	)
+	require.ErrorAs(t, err, &panicErr)
+}
+
+func TestFirstSuccessCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	_, err := parallel.FirstSuccess(ctx, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestHedgedPrimaryFast(t *testing.T) {
+	clk := paralleltest.NewFakeClock(scheduleStart)
+	backupStarted := false
+	res, err := parallel.Hedged(clk.Context(t.Context()), time.Second,
+		func(ctx context.Context) (string, error) {
+			return "primary", nil
+		},
+		func(ctx context.Context) (string, error) {
+			backupStarted = true
+			return "backup", nil
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, "primary", res)
+	require.False(t, backupStarted)
+}
+
+func TestHedgedPrimarySlow(t *testing.T) {
+	clk := paralleltest.NewFakeClock(scheduleStart)
+	backupStarted := make(chan struct{})
+	type result struct {
+		res string
+		err error
+	}
+	resCh := make(chan result, 1)
+	go func() {
+		res, err := parallel.Hedged(clk.Context(t.Context()), time.Second,
+			func(ctx context.Context) (string, error) {
+				<-ctx.Done()
+				return "", ctx.Err()
+			},
+			func(ctx context.Context) (string, error) {
+				close(backupStarted)
+				return "backup", nil
+			},
+		)
+		resCh <- result{res: res, err: err}
+	}()
+
+	// backup is not started until the delay passes
+	require.NoError(t, clk.WaitForTimers(t.Context(), 1))
+	clk.Advance(time.Second - time.Millisecond)
+	select {
+	case <-backupStarted:
+		t.Fatal("backup started before the delay passed")
+	default:
+	}
+	clk.Advance(time.Millisecond)
+
+	r := <-resCh
+	require.NoError(t, r.err)
+	require.Equal(t, "backup", r.res)
+}
+
+func TestHedgedPrimaryFailed(t *testing.T) {
+	// backup is started as soon as the primary fails, without advancing the clock
+	clk := paralleltest.NewFakeClock(scheduleStart)
+	res, err := parallel.Hedged(clk.Context(t.Context()), time.Second,
+		func(ctx context.Context) (string, error) {
+			return "", errors.New("oops")
+		},
+		func(ctx context.Context) (string, error) {
+			return "backup", nil
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, "backup", res)
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 
 	"go.uber.org/zap"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
 )
 
 var (
-	_ Logger = NoOpLogger{}
-	_ Logger = ZapLogger{}
+	_ LevelLogger = NoOpLogger{}
+	_ LevelLogger = ZapLogger{}
 )
 
 // Logger is task log.
@@ -17,6 +19,34 @@ type Logger interface {
 	Error(ctx context.Context, msg string, fields ...zap.Field)
 }
 
+// LevelLogger is task log supporting info and warn levels too.
+type LevelLogger interface {
+	Logger
+	Info(ctx context.Context, msg string, fields ...zap.Field)
+	Warn(ctx context.Context, msg string, fields ...zap.Field)
+}
+
+// levelLogger returns log as LevelLogger, logging info messages at debug level
+// and warnings at error level if it doesn't support them
+func levelLogger(log Logger) LevelLogger {
+	if log, ok := log.(LevelLogger); ok {
+		return log
+	}
+	return levelAdapter{Logger: log}
+}
+
+type levelAdapter struct {
+	Logger
+}
+
+func (a levelAdapter) Info(ctx context.Context, msg string, fields ...zap.Field) {
+	a.Debug(ctx, msg, fields...)
+}
+
+func (a levelAdapter) Warn(ctx context.Context, msg string, fields ...zap.Field) {
+	a.Error(ctx, msg, fields...)
+}
+
 // ********** NoOpLogger **********
 
 // NoOpLogger is no opts log.
@@ -30,27 +60,72 @@ func NewNoOpLogger() NoOpLogger {
 // Debug does nothing.
 func (n NoOpLogger) Debug(_ context.Context, _ string, _ ...zap.Field) {}
 
+// Info does nothing.
+func (n NoOpLogger) Info(_ context.Context, _ string, _ ...zap.Field) {}
+
+// Warn does nothing.
+func (n NoOpLogger) Warn(_ context.Context, _ string, _ ...zap.Field) {}
+
 // Error does nothing.
 func (n NoOpLogger) Error(_ context.Context, _ string, _ ...zap.Field) {}
 
 // ********** ZapLogger **********
 
 // ZapLogger is zap logger.
+//
+// Messages are logged by the zap logger passed to NewZapLogger. If it is nil,
+// each message is logged by the logger taken from the context passed to the
+// method, so the fields added to the context using logger.With are included,
+// or by the default one if the context contains none, see logger.SetDefault.
 type ZapLogger struct {
+	// zapLog reports the caller of ZapLogger, not ZapLogger itself
 	zapLog *zap.Logger
 }
 
-// NewZapLogger returns a new instance of the ZapLogger.
+// NewZapLogger returns a new instance of the ZapLogger. zapLog might be nil,
+// in which case messages are logged by the logger taken from the context.
 func NewZapLogger(zapLog *zap.Logger) ZapLogger {
+	if zapLog != nil {
+		zapLog = zapLog.WithOptions(zap.AddCallerSkip(1))
+	}
 	return ZapLogger{
 		zapLog: zapLog,
 	}
 }
 
-func (z ZapLogger) Debug(_ context.Context, msg string, fields ...zap.Field) {
-	z.zapLog.Debug(msg, fields...)
+// NewContextLogger returns a new instance of the ZapLogger using the loggers
+// taken from the contexts, or the default logger.
+func NewContextLogger() ZapLogger {
+	return NewZapLogger(nil)
+}
+
+// Debug logs message at debug level.
+func (z ZapLogger) Debug(ctx context.Context, msg string, fields ...zap.Field) {
+	z.get(ctx).Debug(msg, fields...)
+}
+
+// Info logs message at info level.
+func (z ZapLogger) Info(ctx context.Context, msg string, fields ...zap.Field) {
+	z.get(ctx).Info(msg, fields...)
 }
 
-func (z ZapLogger) Error(_ context.Context, msg string, fields ...zap.Field) {
-	z.zapLog.Error(msg, fields...)
+// Warn logs message at warn level.
+func (z ZapLogger) Warn(ctx context.Context, msg string, fields ...zap.Field) {
+	z.get(ctx).Warn(msg, fields...)
+}
+
+// Error logs message at error level.
+func (z ZapLogger) Error(ctx context.Context, msg string, fields ...zap.Field) {
+	z.get(ctx).Error(msg, fields...)
+}
+
+func (z ZapLogger) get(ctx context.Context) *zap.Logger {
+	// caller is reported as the code calling ZapLogger, not ZapLogger itself
+	if z.zapLog != nil {
+		return z.zapLog
+	}
+	if log, ok := logger.LookupCallerSkip(ctx); ok {
+		return log
+	}
+	return logger.DefaultCallerSkip()
 }
@@ -0,0 +1,213 @@
+package parallel
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+	"github.com/CoreumFoundation/coreum-tools/pkg/trace"
+)
+
+func TestZapLogger(t *testing.T) {
+	zapCore, zapLogs := observer.New(zapcore.DebugLevel)
+	ctxCore, ctxLogs := observer.New(zapcore.DebugLevel)
+	ctx := logger.With(logger.WithLogger(context.Background(), zap.New(ctxCore)), zap.String("component", "updater"))
+
+	// logger passed explicitly is preferred over the one of the context
+	log := NewZapLogger(zap.New(zapCore))
+	log.Debug(ctx, "debug")
+	log.Info(ctx, "info")
+	log.Warn(ctx, "warn")
+	log.Error(ctx, "error")
+	log.Info(context.Background(), "no context logger")
+	require.Equal(t, 5, zapLogs.Len())
+	require.Zero(t, ctxLogs.Len())
+	levels := []zapcore.Level{}
+	for _, entry := range zapLogs.All() {
+		levels = append(levels, entry.Level)
+	}
+	require.Equal(t, []zapcore.Level{
+		zapcore.DebugLevel, zapcore.InfoLevel, zapcore.WarnLevel, zapcore.ErrorLevel, zapcore.InfoLevel,
+	}, levels)
+
+	// logger of the context is used otherwise
+	log = NewContextLogger()
+	log.Debug(ctx, "debug")
+	log.Info(ctx, "info")
+	log.Warn(ctx, "warn")
+	log.Error(ctx, "error")
+	require.Equal(t, 4, ctxLogs.FilterField(zap.String("component", "updater")).Len())
+
+	// no logger at all
+	log.Info(context.Background(), "dropped")
+
+	defaultCore, defaultLogs := observer.New(zapcore.DebugLevel)
+	logger.SetDefault(zap.New(defaultCore))
+	defer logger.SetDefault(nil)
+	log.Info(context.Background(), "default")
+	require.Equal(t, 1, defaultLogs.FilterMessage("default").Len())
+}
+
+func TestGroupLoggerWithoutLevels(t *testing.T) {
+	log := &levelsRecorder{}
+	runs := 0
+	err := Run(context.Background(), func(ctx context.Context, spawn SpawnFn) error {
+		spawn("unstable", Exit, func(ctx context.Context) error {
+			runs++
+			if runs < 2 {
+				panic("oops")
+			}
+			return nil
+		})
+		return nil
+	}, WithGroupLogger(log), WithPanicPolicy(PanicRestart), WithPanicRestartBackoff(time.Millisecond, time.Millisecond))
+	require.NoError(t, err)
+
+	// warning about the restart is logged at error level
+	require.Contains(t, log.errors, "Restarting task after panic")
+}
+
+// levelsRecorder is the Logger implementing no LevelLogger methods
+type levelsRecorder struct {
+	mu     sync.Mutex
+	errors []string
+}
+
+func (l *levelsRecorder) Debug(_ context.Context, _ string, _ ...zap.Field) {}
+
+func (l *levelsRecorder) Error(_ context.Context, msg string, _ ...zap.Field) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.errors = append(l.errors, msg)
+}
+
+func TestGroupLogsWithContextFields(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctx := logger.With(logger.WithLogger(context.Background(), zap.New(core)), zap.String("component", "updater"))
+
+	err := Run(ctx, func(ctx context.Context, spawn SpawnFn) error {
+		spawn("task", Continue, func(ctx context.Context) error {
+			return nil
+		})
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, logs.FilterField(zap.String("component", "updater")).Len())
+	logs.TakeAll()
+
+	// fields added inside the task are used by the groups it runs
+	err = Run(ctx, func(ctx context.Context, spawn SpawnFn) error {
+		spawn("outer", Continue, func(ctx context.Context) error {
+			ctx = logger.With(ctx, zap.String("worker", "w1"))
+			return Run(ctx, func(ctx context.Context, spawn SpawnFn) error {
+				spawn("inner", Continue, func(ctx context.Context) error {
+					ctx = logger.With(ctx, zap.Int("shard", 7))
+					return Run(ctx, func(ctx context.Context, spawn SpawnFn) error {
+						spawn("nested", Continue, func(ctx context.Context) error {
+							return nil
+						})
+						return nil
+					})
+				})
+				return nil
+			})
+		})
+		return nil
+	})
+	require.NoError(t, err)
+
+	taskFields := map[string]map[string]interface{}{}
+	for _, entry := range logs.FilterMessage("Task finished successfully").All() {
+		fields := entry.ContextMap()
+		taskFields[fields["name"].(string)] = fields
+	}
+	require.Len(t, taskFields, 3)
+	for name, fields := range taskFields {
+		require.Equal(t, "updater", fields["component"], name)
+	}
+	require.NotContains(t, taskFields["outer"], "worker")
+	require.Equal(t, "w1", taskFields["inner"]["worker"])
+	require.NotContains(t, taskFields["inner"], "shard")
+	require.Equal(t, "w1", taskFields["nested"]["worker"])
+	require.EqualValues(t, 7, taskFields["nested"]["shard"])
+	require.Len(t, logs.FilterMessage("Task spawned").FilterField(zap.String("worker", "w1")).All(), 2)
+}
+
+func TestGroupTaskSpans(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctx, _ := trace.Start(logger.WithLogger(context.Background(), zap.New(core)), "test")
+	root, _ := trace.Get(ctx)
+
+	spans := make(chan trace.SpanContext, 2)
+	err := Run(ctx, func(ctx context.Context, spawn SpawnFn) error {
+		for _, name := range []string{"task1", "task2"} {
+			spawn(name, Continue, func(ctx context.Context) error {
+				sc, _ := trace.Get(ctx)
+				spans <- sc
+				logger.Get(ctx).Info("Task running")
+				return nil
+			})
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	close(spans)
+
+	spanIDs := map[string]bool{}
+	for sc := range spans {
+		require.Equal(t, root.TraceID, sc.TraceID)
+		require.NotEqual(t, root.SpanID, sc.SpanID)
+		spanIDs[sc.SpanID.String()] = true
+	}
+	require.Len(t, spanIDs, 2)
+
+	for _, entry := range logs.FilterMessage("Task running").All() {
+		fields := entry.ContextMap()
+		require.Equal(t, root.TraceID.String(), fields[logger.TraceIDKey])
+		require.True(t, spanIDs[fields[logger.SpanIDKey].(string)])
+	}
+	require.Equal(t, 2, logs.FilterMessage("Task running").Len())
+}
+
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []trace.SpanData
+}
+
+func (r *spanRecorder) Export(span trace.SpanData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.spans = append(r.spans, span)
+}
+
+func TestGroupExportsTaskSpans(t *testing.T) {
+	recorder := &spanRecorder{}
+	ctx, root := trace.Start(trace.WithExporter(context.Background(), recorder), "root")
+
+	err := Run(ctx, func(ctx context.Context, spawn SpawnFn) error {
+		spawn("failing", Fail, func(ctx context.Context) error {
+			return errors.New("task failed")
+		})
+		return nil
+	})
+	root.End(err)
+	require.Error(t, err)
+
+	require.Len(t, recorder.spans, 2)
+	task := recorder.spans[0]
+	require.Equal(t, "failing", task.Name)
+	require.Equal(t, trace.StatusError, task.Status)
+	require.Equal(t, "task failed", task.Error)
+	require.Equal(t, "Fail", task.Attributes["onExit"])
+	require.Equal(t, recorder.spans[1].SpanID, *task.ParentSpanID)
+}
@@ -0,0 +1,165 @@
+package paralleltest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/clock"
+)
+
+var _ clock.Clock = &FakeClock{}
+
+// FakeClock is a clock which moves only when told to. Put it into the context
+// using clock.WithClock to control time seen by retry.Do, scheduled tasks and
+// group shutdown timeouts.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []*fakeTimer
+	changed chan struct{}
+}
+
+// NewFakeClock returns a new fake clock set to the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{
+		now:     now,
+		changed: make(chan struct{}),
+	}
+}
+
+// Context returns a copy of the context using the fake clock.
+func (c *FakeClock) Context(ctx context.Context) context.Context {
+	return clock.WithClock(ctx, c)
+}
+
+// Now returns current time of the fake clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// NewTimer creates a new timer which fires when the fake clock is advanced by
+// at least duration d.
+func (c *FakeClock) NewTimer(d time.Duration) clock.Timer {
+	t := &fakeTimer{
+		clock: c,
+		ch:    make(chan time.Time, 1),
+	}
+	t.Reset(d)
+	return t
+}
+
+// Advance moves the fake clock forward by duration d, firing all the timers
+// which expire in the meantime.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	sort.SliceStable(c.timers, func(i, j int) bool {
+		return c.timers[i].deadline.Before(c.timers[j].deadline)
+	})
+	var i int
+	for ; i < len(c.timers) && !c.timers[i].deadline.After(c.now); i++ {
+		c.timers[i].fire(c.now)
+	}
+	c.timers = c.timers[i:]
+	c.notify()
+}
+
+// Timers returns the number of active timers.
+func (c *FakeClock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.timers)
+}
+
+// WaitForTimers blocks until at least n timers are active. It is used to make
+// sure that the code under test waits for the clock before advancing it.
+// It returns ctx.Err() if context is closed earlier.
+func (c *FakeClock) WaitForTimers(ctx context.Context, n int) error {
+	for {
+		c.mu.Lock()
+		active := len(c.timers)
+		changed := c.changed
+		c.mu.Unlock()
+
+		if active >= n {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+func (c *FakeClock) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+func (c *FakeClock) remove(t *fakeTimer) bool {
+	for i, t2 := range c.timers {
+		if t2 == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			c.notify()
+			return true
+		}
+	}
+	return false
+}
+
+type fakeTimer struct {
+	clock    *FakeClock
+	ch       chan time.Time
+	deadline time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.drain()
+	return t.clock.remove(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.drain()
+	active := t.clock.remove(t)
+	t.deadline = t.clock.now.Add(d)
+	if d <= 0 {
+		t.fire(t.clock.now)
+		return active
+	}
+	t.clock.timers = append(t.clock.timers, t)
+	t.clock.notify()
+	return active
+}
+
+func (t *fakeTimer) fire(now time.Time) {
+	select {
+	case t.ch <- now:
+	default:
+	}
+}
+
+func (t *fakeTimer) drain() {
+	select {
+	case <-t.ch:
+	default:
+	}
+}
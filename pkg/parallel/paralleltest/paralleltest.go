@@ -0,0 +1,185 @@
+// Package paralleltest provides tools for deterministic testing of code built
+// on top of package parallel.
+package paralleltest
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/parallel"
+)
+
+// WaitTimeout is the time test helpers wait for the expected state before
+// failing the test
+var WaitTimeout = 5 * time.Second
+
+const pollInterval = time.Millisecond
+
+// RequireRunning fails the test if the set of subtasks running in the group
+// does not become equal to the expected one within WaitTimeout.
+func RequireRunning(t testing.TB, group *parallel.Group, names ...string) {
+	t.Helper()
+
+	expected := append([]string{}, names...)
+	sort.Strings(expected)
+
+	var running []string
+	deadline := time.Now().Add(WaitTimeout)
+	for {
+		running = group.RunningTasks()
+		sort.Strings(running)
+		if slices.Equal(running, expected) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected running tasks %q, got %q", expected, running)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// VerifyNoLeaks fails the test if, at the end of it, there are goroutines
+// which did not exist when VerifyNoLeaks was called. Goroutines are given
+// WaitTimeout to exit.
+//
+// Call it at the beginning of the test:
+//
+//	func TestService(t *testing.T) {
+//	    paralleltest.VerifyNoLeaks(t)
+//	    ...
+//	}
+func VerifyNoLeaks(t testing.TB) {
+	t.Helper()
+
+	initial := map[string]bool{}
+	for id := range goroutines() {
+		initial[id] = true
+	}
+
+	t.Cleanup(func() {
+		t.Helper()
+
+		var leaked []string
+		deadline := time.Now().Add(WaitTimeout)
+		for {
+			leaked = leaked[:0]
+			for id, stack := range goroutines() {
+				if !initial[id] {
+					leaked = append(leaked, stack)
+				}
+			}
+			if len(leaked) == 0 {
+				return
+			}
+			if time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(pollInterval)
+		}
+		sort.Strings(leaked)
+		t.Errorf("%d goroutine(s) leaked:\n\n%s", len(leaked), strings.Join(leaked, "\n\n"))
+	})
+}
+
+// goroutines returns stacks of all the goroutines except the current one, keyed by goroutine ID
+func goroutines() map[string]string {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	res := map[string]string{}
+	for i, stack := range strings.Split(string(buf), "\n\n") {
+		// the first one is the current goroutine
+		if i == 0 {
+			continue
+		}
+		// the header looks like "goroutine 42 [running]:"
+		header, _, _ := strings.Cut(stack, "\n")
+		fields := strings.Fields(header)
+		if len(fields) < 2 {
+			continue
+		}
+		res[fields[1]] = stack
+	}
+	return res
+}
+
+// Stepper lets the test drive tasks step by step. The task calls Step at the
+// points of interest, blocking there until the test lets it continue by
+// calling Next with the same step name.
+//
+// Example:
+//
+//	stepper := paralleltest.NewStepper()
+//	group.Spawn("worker", parallel.Fail, func(ctx context.Context) error {
+//	    if err := stepper.Step(ctx, "fetched"); err != nil {
+//	        return err
+//	    }
+//	    ...
+//	})
+//	stepper.Next(t, "fetched")
+type Stepper struct {
+	mu    sync.Mutex
+	steps map[string]chan chan struct{}
+}
+
+// NewStepper returns a new stepper.
+func NewStepper() *Stepper {
+	return &Stepper{
+		steps: map[string]chan chan struct{}{},
+	}
+}
+
+// Step blocks until the test calls Next for the step. It returns ctx.Err() if
+// context is closed earlier.
+func (s *Stepper) Step(ctx context.Context, name string) error {
+	release := make(chan struct{})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case s.step(name) <- release:
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-release:
+		return nil
+	}
+}
+
+// Next waits until a task reaches the step and lets it continue. It fails the
+// test if no task reaches the step within WaitTimeout.
+func (s *Stepper) Next(t testing.TB, name string) {
+	t.Helper()
+
+	select {
+	case <-time.After(WaitTimeout):
+		t.Fatalf("no task reached step %q", name)
+	case release := <-s.step(name):
+		close(release)
+	}
+}
+
+func (s *Stepper) step(name string) chan chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch, ok := s.steps[name]
+	if !ok {
+		ch = make(chan chan struct{})
+		s.steps[name] = ch
+	}
+	return ch
+}
@@ -0,0 +1,193 @@
+package paralleltest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/parallel"
+	"github.com/CoreumFoundation/coreum-tools/pkg/parallel/paralleltest"
+	"github.com/CoreumFoundation/coreum-tools/pkg/retry"
+)
+
+var start = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFakeClock(t *testing.T) {
+	clk := paralleltest.NewFakeClock(start)
+	timer1 := clk.NewTimer(time.Second)
+	timer2 := clk.NewTimer(2 * time.Second)
+	timer3 := clk.NewTimer(3 * time.Second)
+	require.Equal(t, 3, clk.Timers())
+
+	require.True(t, timer3.Stop())
+	require.False(t, timer3.Stop())
+	require.Equal(t, 2, clk.Timers())
+
+	clk.Advance(time.Second)
+	require.Equal(t, start.Add(time.Second), <-timer1.C())
+	require.Equal(t, 1, clk.Timers())
+
+	require.True(t, timer2.Reset(5*time.Second))
+	clk.Advance(4 * time.Second)
+	select {
+	case <-timer2.C():
+		t.Fatal("timer fired too early")
+	default:
+	}
+	clk.Advance(time.Second)
+	require.Equal(t, start.Add(6*time.Second), <-timer2.C())
+	require.Zero(t, clk.Timers())
+}
+
+func TestRetryWithFakeClock(t *testing.T) {
+	paralleltest.VerifyNoLeaks(t)
+
+	clk := paralleltest.NewFakeClock(start)
+	ctx := clk.Context(t.Context())
+
+	var attempts int
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- retry.Do(ctx, time.Hour, func() error {
+			attempts++
+			if attempts < 3 {
+				return retry.Retryable(errors.New("oops"))
+			}
+			return nil
+		})
+	}()
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, clk.WaitForTimers(ctx, 1))
+		clk.Advance(time.Hour)
+	}
+	require.NoError(t, <-errCh)
+	require.Equal(t, 3, attempts)
+	require.Equal(t, start.Add(2*time.Hour), clk.Now())
+}
+
+func runScheduled(t *testing.T, policy parallel.OverlapPolicy, runs int, drive func(clk *paralleltest.FakeClock)) []time.Time {
+	clk := paralleltest.NewFakeClock(start)
+	ctx, cancel := context.WithCancel(clk.Context(t.Context()))
+	defer cancel()
+
+	var times []time.Time
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- parallel.Periodic(10*time.Second, func(ctx context.Context) error {
+			times = append(times, clk.Now())
+			if len(times) == 1 {
+				// the first run takes longer than the interval
+				clk.Advance(25 * time.Second)
+			}
+			if len(times) == runs {
+				cancel()
+			}
+			return nil
+		}, parallel.WithOverlapPolicy(policy))(ctx)
+	}()
+
+	drive(clk)
+	require.ErrorIs(t, <-errCh, context.Canceled)
+	return times
+}
+
+func TestPeriodicSkip(t *testing.T) {
+	paralleltest.VerifyNoLeaks(t)
+
+	times := runScheduled(t, parallel.Skip, 2, func(clk *paralleltest.FakeClock) {
+		require.NoError(t, clk.WaitForTimers(t.Context(), 1))
+		clk.Advance(10 * time.Second)
+		require.NoError(t, clk.WaitForTimers(t.Context(), 1))
+		clk.Advance(5 * time.Second)
+	})
+	require.Equal(t, []time.Time{start.Add(10 * time.Second), start.Add(40 * time.Second)}, times)
+}
+
+func TestPeriodicQueue(t *testing.T) {
+	paralleltest.VerifyNoLeaks(t)
+
+	times := runScheduled(t, parallel.Queue, 4, func(clk *paralleltest.FakeClock) {
+		require.NoError(t, clk.WaitForTimers(t.Context(), 1))
+		clk.Advance(10 * time.Second)
+		require.NoError(t, clk.WaitForTimers(t.Context(), 1))
+		clk.Advance(5 * time.Second)
+	})
+	require.Equal(t, []time.Time{
+		start.Add(10 * time.Second),
+		start.Add(35 * time.Second),
+		start.Add(35 * time.Second),
+		start.Add(40 * time.Second),
+	}, times)
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	paralleltest.VerifyNoLeaks(t)
+
+	clk := paralleltest.NewFakeClock(start)
+	stepper := paralleltest.NewStepper()
+	release := make(chan struct{})
+
+	group := parallel.NewGroup(clk.Context(t.Context()), parallel.WithShutdownTimeout(time.Minute))
+	group.Spawn("stuck", parallel.Fail, func(ctx context.Context) error {
+		// context is ignored on purpose
+		<-release
+		return nil
+	})
+	group.Spawn("exit", parallel.Exit, func(ctx context.Context) error {
+		return stepper.Step(ctx, "exit")
+	})
+	paralleltest.RequireRunning(t, group, "stuck", "exit")
+
+	stepper.Next(t, "exit")
+	paralleltest.RequireRunning(t, group, "stuck")
+
+	require.NoError(t, clk.WaitForTimers(t.Context(), 1))
+	clk.Advance(time.Minute)
+	require.ErrorIs(t, group.Wait(), parallel.ErrShutdownTimeout)
+	paralleltest.RequireRunning(t, group, "stuck")
+
+	close(release)
+	<-group.Done()
+	paralleltest.RequireRunning(t, group)
+}
+
+func TestPanicRestartBackoff(t *testing.T) {
+	paralleltest.VerifyNoLeaks(t)
+
+	clk := paralleltest.NewFakeClock(start)
+	var runs []time.Time
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- parallel.Run(clk.Context(t.Context()), func(ctx context.Context, spawn parallel.SpawnFn) error {
+			spawn("unstable", parallel.Fail, func(ctx context.Context) error {
+				runs = append(runs, clk.Now())
+				panic("oops")
+			})
+			return nil
+		}, parallel.WithPanicPolicy(parallel.PanicRestart),
+			parallel.WithPanicRestartBackoff(time.Second, 3*time.Second),
+			parallel.WithMaxPanicRestarts(4))
+	}()
+
+	// delays are doubled up to the maximum
+	for _, delay := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second} {
+		require.NoError(t, clk.WaitForTimers(t.Context(), 1))
+		clk.Advance(delay)
+	}
+
+	// once the limit is exceeded, the panic fails the group
+	var errPanic parallel.ErrPanic
+	require.True(t, errors.As(<-errCh, &errPanic))
+	require.Equal(t, "oops", errPanic.Value)
+	require.Equal(t, []time.Time{
+		start,
+		start.Add(time.Second),
+		start.Add(3 * time.Second),
+		start.Add(6 * time.Second),
+		start.Add(9 * time.Second),
+	}, runs)
+}
@@ -0,0 +1,277 @@
+package parallel
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// EmitFn passes an item to the next stage of the pipeline. It blocks until the
+// next stage is ready to accept the item or the pipeline is shutting down, in
+// which case ctx.Err() is returned.
+type EmitFn[T any] func(item T) error
+
+// StageOption is an option of a pipeline stage.
+type StageOption func(o *stageOptions)
+
+type stageOptions struct {
+	concurrency int
+	buffer      int
+	ordered     bool
+}
+
+// WithConcurrency sets the number of goroutines processing items of the stage.
+// Default is 1. It is ignored by sources.
+func WithConcurrency(concurrency int) StageOption {
+	return func(o *stageOptions) {
+		o.concurrency = concurrency
+	}
+}
+
+// WithBuffer sets the number of items which might be produced by the stage
+// before the next one accepts them. Default is 0, meaning the stage blocks
+// until the next one is ready.
+func WithBuffer(buffer int) StageOption {
+	return func(o *stageOptions) {
+		o.buffer = buffer
+	}
+}
+
+// WithOrderedOutput makes the stage produce items in the order of the items
+// it receives, even if they are processed concurrently.
+func WithOrderedOutput() StageOption {
+	return func(o *stageOptions) {
+		o.ordered = true
+	}
+}
+
+func newStageOptions(options []StageOption) stageOptions {
+	opts := stageOptions{
+		concurrency: 1,
+	}
+	for _, o := range options {
+		o(&opts)
+	}
+	if opts.concurrency < 1 {
+		opts.concurrency = 1
+	}
+	if opts.buffer < 0 {
+		opts.buffer = 0
+	}
+	return opts
+}
+
+// Pipeline is a chain of stages processing a stream of items. Each stage runs
+// in its own goroutines, passing items to the next one through bounded
+// buffers, so a slow stage holds back the previous ones.
+//
+// Pipeline is built by Source, extended by Map and FlatMap, and turned into a
+// task by Sink. All the stages run as subtasks of a single Group: if any of
+// them returns an error or panics, the whole pipeline is shut down and the
+// error is returned by the task.
+//
+// Example:
+//
+//	blocks := parallel.Source("fetch", fetcher.Fetch, parallel.WithBuffer(10))
+//	txs := parallel.Map(blocks, "decode", decodeBlock,
+//	    parallel.WithConcurrency(4), parallel.WithOrderedOutput())
+//	spawn("pipeline", parallel.Fail, txs.Sink("store", db.Store))
+type Pipeline[T any] struct {
+	start func(ctx context.Context, spawn SpawnFn) <-chan T
+}
+
+// Source creates a pipeline whose items are produced by the given function.
+// Once it returns nil, the stream is finished, and the pipeline completes after
+// all the items are processed by the remaining stages.
+func Source[T any](name string, produce func(ctx context.Context, emit EmitFn[T]) error, options ...StageOption) *Pipeline[T] {
+	opts := newStageOptions(options)
+	return &Pipeline[T]{
+		start: func(ctx context.Context, spawn SpawnFn) <-chan T {
+			out := make(chan T, opts.buffer)
+			spawn(name, Continue, func(ctx context.Context) error {
+				if err := produce(ctx, newEmitFn(ctx, out)); err != nil {
+					return err
+				}
+				close(out)
+				return nil
+			})
+			return out
+		},
+	}
+}
+
+// Map appends a stage to the pipeline which transforms each item using the
+// given function.
+func Map[In, Out any](p *Pipeline[In], name string, fn func(ctx context.Context, item In) (Out, error), options ...StageOption) *Pipeline[Out] {
+	return FlatMap(p, name, func(ctx context.Context, item In, emit EmitFn[Out]) error {
+		res, err := fn(ctx, item)
+		if err != nil {
+			return err
+		}
+		return emit(res)
+	}, options...)
+}
+
+// FlatMap appends a stage to the pipeline which might produce any number of
+// items, including none, for each item it receives.
+func FlatMap[In, Out any](p *Pipeline[In], name string, fn func(ctx context.Context, item In, emit EmitFn[Out]) error, options ...StageOption) *Pipeline[Out] {
+	opts := newStageOptions(options)
+	return &Pipeline[Out]{
+		start: func(ctx context.Context, spawn SpawnFn) <-chan Out {
+			in := p.start(ctx, spawn)
+			out := make(chan Out, opts.buffer)
+			if opts.ordered {
+				startOrderedStage(spawn, name, opts, in, out, fn)
+			} else {
+				startStage(spawn, name, opts, in, func(ctx context.Context, item In) error {
+					return fn(ctx, item, newEmitFn(ctx, out))
+				}, func() {
+					close(out)
+				})
+			}
+			return out
+		},
+	}
+}
+
+// Sink returns a task running the pipeline, with the last stage consuming the
+// items using the given function.
+//
+// The task returns nil when all the items produced by the source have been
+// consumed. WithOrderedOutput has no effect on the sink.
+func (p *Pipeline[T]) Sink(name string, consume func(ctx context.Context, item T) error, options ...StageOption) Task {
+	opts := newStageOptions(options)
+	return func(ctx context.Context) error {
+		return Run(ctx, func(ctx context.Context, spawn SpawnFn) error {
+			startStage(spawn, name, opts, p.start(ctx, spawn), consume, func() {})
+			return nil
+		})
+	}
+}
+
+func startStage[In any](
+	spawn SpawnFn,
+	name string,
+	opts stageOptions,
+	in <-chan In,
+	process func(ctx context.Context, item In) error,
+	finish func(),
+) {
+	remaining := int32(opts.concurrency)
+	for i := 0; i < opts.concurrency; i++ {
+		spawn(fmt.Sprintf("%s-%d", name, i), Continue, func(ctx context.Context) error {
+			for {
+				item, err := receive(ctx, in)
+				if err != nil {
+					return err
+				}
+				if item == nil {
+					if atomic.AddInt32(&remaining, -1) == 0 {
+						finish()
+					}
+					return nil
+				}
+				if err := process(ctx, *item); err != nil {
+					return err
+				}
+			}
+		})
+	}
+}
+
+func startOrderedStage[In, Out any](
+	spawn SpawnFn,
+	name string,
+	opts stageOptions,
+	in <-chan In,
+	out chan<- Out,
+	fn func(ctx context.Context, item In, emit EmitFn[Out]) error,
+) {
+	type job struct {
+		item   In
+		result chan<- []Out
+	}
+
+	// pending delivers result channels in the order of incoming items, its
+	// capacity limits the number of items being processed at once
+	pending := make(chan chan []Out, opts.concurrency+opts.buffer)
+	jobs := make(chan job)
+
+	spawn(name+"-dispatcher", Continue, func(ctx context.Context) error {
+		for {
+			item, err := receive(ctx, in)
+			if err != nil {
+				return err
+			}
+			if item == nil {
+				close(jobs)
+				close(pending)
+				return nil
+			}
+			result := make(chan []Out, 1)
+			if err := newEmitFn(ctx, pending)(result); err != nil {
+				return err
+			}
+			if err := newEmitFn(ctx, jobs)(job{item: *item, result: result}); err != nil {
+				return err
+			}
+		}
+	})
+	startStage(spawn, name, opts, jobs, func(ctx context.Context, j job) error {
+		var results []Out
+		if err := fn(ctx, j.item, func(item Out) error {
+			results = append(results, item)
+			return nil
+		}); err != nil {
+			return err
+		}
+		j.result <- results
+		return nil
+	}, func() {})
+	spawn(name+"-collector", Continue, func(ctx context.Context) error {
+		emit := newEmitFn(ctx, out)
+		for {
+			result, err := receive(ctx, pending)
+			if err != nil {
+				return err
+			}
+			if result == nil {
+				close(out)
+				return nil
+			}
+			items, err := receive(ctx, *result)
+			if err != nil {
+				return err
+			}
+			for _, item := range *items {
+				if err := emit(item); err != nil {
+					return err
+				}
+			}
+		}
+	})
+}
+
+func newEmitFn[T any](ctx context.Context, ch chan<- T) EmitFn[T] {
+	return func(item T) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ch <- item:
+			return nil
+		}
+	}
+}
+
+// receive returns nil item if the channel is closed
+func receive[T any](ctx context.Context, ch <-chan T) (*T, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case item, ok := <-ch:
+		if !ok {
+			return nil, nil
+		}
+		return &item, nil
+	}
+}
@@ -0,0 +1,126 @@
+package parallel
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func numbers(n int) func(ctx context.Context, emit EmitFn[int]) error {
+	return func(ctx context.Context, emit EmitFn[int]) error {
+		for i := 0; i < n; i++ {
+			if err := emit(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestPipelineUnordered(t *testing.T) {
+	var mu sync.Mutex
+	var results []string
+
+	p := Map(Source("source", numbers(100), WithBuffer(5)), "format", func(ctx context.Context, item int) (string, error) {
+		return strconv.Itoa(item * 2), nil
+	}, WithConcurrency(4), WithBuffer(2))
+	err := p.Sink("sink", func(ctx context.Context, item string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		results = append(results, item)
+		return nil
+	}, WithConcurrency(2))(t.Context())
+	require.NoError(t, err)
+
+	require.Len(t, results, 100)
+	sort.Slice(results, func(i, j int) bool {
+		a, _ := strconv.Atoi(results[i])
+		b, _ := strconv.Atoi(results[j])
+		return a < b
+	})
+	for i, r := range results {
+		require.Equal(t, strconv.Itoa(i*2), r)
+	}
+}
+
+func TestPipelineOrdered(t *testing.T) {
+	var results []int
+
+	p := Map(Source("source", numbers(50)), "delay", func(ctx context.Context, item int) (int, error) {
+		// later items are processed faster
+		time.Sleep(time.Duration(50-item) * 10 * time.Microsecond)
+		return item, nil
+	}, WithConcurrency(8), WithOrderedOutput())
+	err := p.Sink("sink", func(ctx context.Context, item int) error {
+		results = append(results, item)
+		return nil
+	})(t.Context())
+	require.NoError(t, err)
+
+	require.Len(t, results, 50)
+	for i, r := range results {
+		require.Equal(t, i, r)
+	}
+}
+
+func TestPipelineFlatMap(t *testing.T) {
+	var results []int
+
+	p := FlatMap(Source("source", numbers(10)), "evenTwice", func(ctx context.Context, item int, emit EmitFn[int]) error {
+		if item%2 != 0 {
+			return nil
+		}
+		if err := emit(item); err != nil {
+			return err
+		}
+		return emit(item)
+	}, WithConcurrency(3), WithOrderedOutput())
+	err := p.Sink("sink", func(ctx context.Context, item int) error {
+		results = append(results, item)
+		return nil
+	})(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 0, 2, 2, 4, 4, 6, 6, 8, 8}, results)
+}
+
+func TestPipelineError(t *testing.T) {
+	sourceStopped := make(chan struct{})
+	source := Source("source", func(ctx context.Context, emit EmitFn[int]) error {
+		defer close(sourceStopped)
+		for i := 0; ; i++ {
+			if err := emit(i); err != nil {
+				return err
+			}
+		}
+	})
+	p := Map(source, "fail", func(ctx context.Context, item int) (int, error) {
+		if item == 10 {
+			return 0, errors.New("oops")
+		}
+		return item, nil
+	}, WithConcurrency(2), WithOrderedOutput())
+	err := p.Sink("sink", func(ctx context.Context, item int) error {
+		return nil
+	})(t.Context())
+	require.EqualError(t, err, "oops")
+	<-sourceStopped
+}
+
+func TestPipelineCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	source := Source("source", func(ctx context.Context, emit EmitFn[int]) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	cancel()
+	err := source.Sink("sink", func(ctx context.Context, item int) error {
+		return nil
+	})(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -3,21 +3,72 @@ package parallel
 import (
 	"context"
 	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strings"
 
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
-	"runtime/debug"
 )
 
+// maxPanicFrames limits the number of frames collected for ErrPanic
+const maxPanicFrames = 64
+
+var runTaskWithRecoveryName string
+
+func init() {
+	runTaskWithRecoveryName = runtime.FuncForPC(reflect.ValueOf(runTaskWithRecovery).Pointer()).Name()
+}
+
 // ErrPanic is the error type that occurs when a subtask panics
 type ErrPanic struct {
 	Value interface{}
 	Stack []byte
+
+	// Frames is the stack of the panic location, trimmed of the frames
+	// belonging to the panic and recovery machinery
+	Frames []StackFrame
+
+	stackTrace errors.StackTrace
+}
+
+// StackFrame is a single frame of the panic stack.
+type StackFrame struct {
+	Function string
+	File     string
+	Line     int
 }
 
 func (err ErrPanic) Error() string {
 	return fmt.Sprintf("panic: %s", err.Value)
 }
 
+// StackTrace returns the stack of the panic location in the form used by
+// github.com/pkg/errors.
+func (err ErrPanic) StackTrace() errors.StackTrace {
+	return err.stackTrace
+}
+
+// Format formats the error the same way github.com/pkg/errors does: %+v
+// prints the stack of the panic location after the message.
+func (err ErrPanic) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			_, _ = io.WriteString(s, err.Error())
+			err.stackTrace.Format(s, verb)
+			return
+		}
+		fallthrough
+	case 's':
+		_, _ = io.WriteString(s, err.Error())
+	case 'q':
+		_, _ = fmt.Fprintf(s, "%q", err.Error())
+	}
+}
+
 // Unwrap returns the error passed to panic, or nil if panic was called with
 // something other than an error
 func (err ErrPanic) Unwrap() error {
@@ -27,22 +78,131 @@ func (err ErrPanic) Unwrap() error {
 	return nil
 }
 
+// PanicPolicy is an enumeration of modes specifying how panics in subtasks
+// are handled.
+//
+// Regardless of the chosen mode, the panic is logged and passed to the panic
+// hook, if any is set using WithPanicHook.
+type PanicPolicy int
+
+const (
+	// PanicFail means the panic is recovered and returned by the subtask as
+	// ErrPanic, causing the group to shut down and return that error.
+	PanicFail PanicPolicy = iota
+
+	// PanicRestart means the panic is recovered and the subtask is started
+	// again after a delay, unless the group is shutting down already, see
+	// WithPanicRestartBackoff and WithMaxPanicRestarts.
+	PanicRestart
+
+	// PanicRepanic means the panic is raised again with ErrPanic as its value,
+	// crashing the process. Use this mode to make crashes visible to the test
+	// framework or to the crash reporter.
+	PanicRepanic
+)
+
+func (p PanicPolicy) String() string {
+	switch p {
+	case PanicFail:
+		return "PanicFail"
+	case PanicRestart:
+		return "PanicRestart"
+	case PanicRepanic:
+		return "PanicRepanic"
+	default:
+		return fmt.Sprintf("invalid PanicPolicy: %d", p)
+	}
+}
+
+// PanicHook is called for each panic in a subtask, e.g. to forward it to an
+// error tracker. It is called in the goroutine of the subtask.
+type PanicHook func(ctx context.Context, err ErrPanic)
+
 // runTaskWithRecovery executes the task in the current goroutine, recovering from panics.
-// A panic is returned as ErrPanic.
-func runTaskWithRecovery(ctx context.Context, log Logger, name string, id int64, onExit OnExit, task Task) (err error) {
+// A panic is returned as ErrPanic, or raised again if policy is PanicRepanic.
+// The first result reports whether the task panicked.
+func runTaskWithRecovery(
+	ctx context.Context,
+	log Logger,
+	policy PanicPolicy,
+	hook PanicHook,
+	name string,
+	id int64,
+	onExit OnExit,
+	task Task,
+) (panicked bool, err error) {
 	defer func() {
 		if p := recover(); p != nil {
-			panicErr := ErrPanic{Value: p, Stack: debug.Stack()}
+			panicErr := newErrPanic(p)
 			err = panicErr
+			panicked = true
 			log.Error(
 				ctx,
 				"Panic",
 				zap.String("name", name),
 				zap.Int64("id", id),
 				zap.String("onExit", onExit.String()),
+				zap.String("policy", policy.String()),
 				zap.Error(err),
 			)
+			if hook != nil {
+				hook(ctx, panicErr)
+			}
+			if policy == PanicRepanic {
+				panic(panicErr)
+			}
 		}
 	}()
-	return task(ctx)
+	return false, task(ctx)
+}
+
+// newErrPanic must be called by the function deferred by runTaskWithRecovery
+func newErrPanic(value interface{}) ErrPanic {
+	pcs := make([]uintptr, maxPanicFrames)
+	pcs = pcs[:runtime.Callers(3, pcs)]
+
+	// frames of runtime.gopanic and the functions of runtime raising the panic are skipped
+	for i, pc := range pcs {
+		if funcName(pc) == "runtime.gopanic" {
+			pcs = pcs[i+1:]
+			for len(pcs) > 0 && strings.HasPrefix(funcName(pcs[0]), "runtime.") {
+				pcs = pcs[1:]
+			}
+			break
+		}
+	}
+	// frames of runTaskWithRecovery and its callers are skipped
+	for i, pc := range pcs {
+		if funcName(pc) == runTaskWithRecoveryName {
+			pcs = pcs[:i]
+			break
+		}
+	}
+
+	stackTrace := make(errors.StackTrace, 0, len(pcs))
+	frames := make([]StackFrame, 0, len(pcs))
+	for _, pc := range pcs {
+		stackTrace = append(stackTrace, errors.Frame(pc))
+		frame := StackFrame{Function: "unknown", File: "unknown"}
+		if fn := runtime.FuncForPC(pc - 1); fn != nil {
+			frame.Function = fn.Name()
+			frame.File, frame.Line = fn.FileLine(pc - 1)
+		}
+		frames = append(frames, frame)
+	}
+
+	return ErrPanic{
+		Value:      value,
+		Stack:      debug.Stack(),
+		Frames:     frames,
+		stackTrace: stackTrace,
+	}
+}
+
+func funcName(pc uintptr) string {
+	fn := runtime.FuncForPC(pc - 1)
+	if fn == nil {
+		return ""
+	}
+	return fn.Name()
 }
@@ -3,7 +3,12 @@ package parallel
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -17,12 +22,12 @@ func panicWith(value interface{}) error {
 func TestPanicString(t *testing.T) {
 	ctx := logger.WithLogger(context.Background(), logger.New(logger.ToolDefaultConfig))
 	var err ErrPanic
-	errors.As(Run(ctx, func(ctx context.Context, spawn SpawnFn) error {
+	require.True(t, errors.As(Run(ctx, func(ctx context.Context, spawn SpawnFn) error {
 		spawn("doomed", Fail, func(ctx context.Context) error {
 			return panicWith("oops")
 		})
 		return nil
-	}), &err)
+	}), &err))
 	require.Nil(t, err.Unwrap())
 	require.EqualError(t, err, "panic: oops")
 	require.Equal(t, "oops", err.Value)
@@ -34,12 +39,12 @@ func TestPanicString(t *testing.T) {
 func TestPanicError(t *testing.T) {
 	ctx := logger.WithLogger(context.Background(), logger.New(logger.ToolDefaultConfig))
 	var err ErrPanic
-	errors.As(Run(ctx, func(ctx context.Context, spawn SpawnFn) error {
+	require.True(t, errors.As(Run(ctx, func(ctx context.Context, spawn SpawnFn) error {
 		spawn("doomed", Fail, func(ctx context.Context) error {
 			return panicWith(errors.New("oops"))
 		})
 		return nil
-	}), &err)
+	}), &err))
 	require.Equal(t, errors.New("oops"), err.Unwrap())
 	require.EqualError(t, err, "panic: oops")
 	require.Equal(t, errors.New("oops"), err.Value)
@@ -48,15 +53,96 @@ func TestPanicError(t *testing.T) {
 	require.Regexp(t, "(?s)^goroutine.*panicWith", string(err.Stack))
 }
 
+func TestPanicFrames(t *testing.T) {
+	ctx := logger.WithLogger(context.Background(), logger.New(logger.ToolDefaultConfig))
+	var err ErrPanic
+	require.True(t, errors.As(Run(ctx, func(ctx context.Context, spawn SpawnFn) error {
+		spawn("doomed", Fail, func(ctx context.Context) error {
+			return panicWith("oops")
+		})
+		return nil
+	}), &err))
+
+	require.NotEmpty(t, err.Frames)
+	require.Len(t, err.StackTrace(), len(err.Frames))
+	// the stack starts at the panic location and ends at the task
+	require.True(t, strings.HasSuffix(err.Frames[0].Function, "parallel.panicWith"), err.Frames[0].Function)
+	require.True(t, strings.HasSuffix(err.Frames[0].File, "recover_test.go"), err.Frames[0].File)
+	require.NotZero(t, err.Frames[0].Line)
+	require.True(t, strings.HasSuffix(err.Frames[len(err.Frames)-1].Function, "TestPanicFrames.func1.1"))
+	for _, frame := range err.Frames {
+		require.NotContains(t, frame.Function, "runtime.")
+		require.NotContains(t, frame.Function, "runTask")
+	}
+
+	require.Equal(t, "panic: oops", fmt.Sprintf("%v", err))
+	formatted := fmt.Sprintf("%+v", err)
+	require.Regexp(t, `(?s)^panic: oops\n.*panicWith\n\t.*recover_test.go:\d+`, formatted)
+	require.NotContains(t, formatted, "runTaskWithRecovery")
+}
+
 func TestPanicErrorWithCustomLogger(t *testing.T) {
 	ctx := context.Background()
 	log := &LoggerMock{}
 	var err ErrPanic
-	errors.As(Run(ctx, func(ctx context.Context, spawn SpawnFn) error {
+	require.True(t, errors.As(Run(ctx, func(ctx context.Context, spawn SpawnFn) error {
 		spawn("doomed", Fail, func(ctx context.Context) error {
 			return panicWith(errors.New("oops"))
 		})
 		return nil
-	}, WithGroupLogger(log)), &err)
+	}, WithGroupLogger(log)), &err))
 	require.Equal(t, int32(2), log.errorCalls)
 }
+
+func TestPanicRestart(t *testing.T) {
+	ctx := logger.WithLogger(context.Background(), logger.New(logger.ToolDefaultConfig))
+	var runs int
+	err := Run(ctx, func(ctx context.Context, spawn SpawnFn) error {
+		spawn("unstable", Exit, func(ctx context.Context) error {
+			runs++
+			if runs < 3 {
+				return panicWith("oops")
+			}
+			return nil
+		})
+		return nil
+	}, WithPanicPolicy(PanicRestart), WithPanicRestartBackoff(time.Millisecond, time.Millisecond))
+	require.NoError(t, err)
+	require.Equal(t, 3, runs)
+}
+
+func TestPanicHook(t *testing.T) {
+	ctx := logger.WithLogger(context.Background(), logger.New(logger.ToolDefaultConfig))
+	var hookErr ErrPanic
+	err := Run(ctx, func(ctx context.Context, spawn SpawnFn) error {
+		spawn("doomed", Fail, func(ctx context.Context) error {
+			return panicWith("oops")
+		})
+		return nil
+	}, WithPanicHook(func(ctx context.Context, err ErrPanic) {
+		hookErr = err
+	}))
+	require.EqualError(t, err, "panic: oops")
+	require.Equal(t, "oops", hookErr.Value)
+	require.Regexp(t, "(?s)^goroutine.*panicWith", string(hookErr.Stack))
+}
+
+func TestPanicRepanic(t *testing.T) {
+	if os.Getenv("PARALLEL_TEST_REPANIC") == "1" {
+		_ = Run(context.Background(), func(ctx context.Context, spawn SpawnFn) error {
+			spawn("doomed", Fail, func(ctx context.Context) error {
+				return panicWith("oops")
+			})
+			return nil
+		}, WithPanicPolicy(PanicRepanic))
+		return
+	}
+
+	// the process crashes, so the test is executed in a subprocess
+	cmd := exec.Command(os.Args[0], "-test.run=^TestPanicRepanic$")
+	cmd.Env = append(os.Environ(), "PARALLEL_TEST_REPANIC=1")
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	require.Contains(t, string(out), "panic: oops")
+}
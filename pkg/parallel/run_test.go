@@ -340,10 +340,12 @@ func TestExitFailTaskOnCancel(t *testing.T) {
 	require.NoError(t, err)
 }
 
-var _ Logger = &LoggerMock{}
+var _ LevelLogger = &LoggerMock{}
 
 type LoggerMock struct {
 	debugCalls int32
+	infoCalls  int32
+	warnCalls  int32
 	errorCalls int32
 }
 
@@ -351,6 +353,14 @@ func (l *LoggerMock) Debug(_ context.Context, _ string, _ ...zap.Field) {
 	atomic.AddInt32(&l.debugCalls, 1)
 }
 
+func (l *LoggerMock) Info(_ context.Context, _ string, _ ...zap.Field) {
+	atomic.AddInt32(&l.infoCalls, 1)
+}
+
+func (l *LoggerMock) Warn(_ context.Context, _ string, _ ...zap.Field) {
+	atomic.AddInt32(&l.warnCalls, 1)
+}
+
 func (l *LoggerMock) Error(_ context.Context, _ string, _ ...zap.Field) {
 	atomic.AddInt32(&l.errorCalls, 1)
 }
@@ -0,0 +1,188 @@
+package parallel
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/clock"
+)
+
+// Schedule computes activation times of a scheduled task.
+type Schedule interface {
+	// Next returns the first activation time strictly after t. Zero time is
+	// returned if there is no such activation.
+	Next(t time.Time) time.Time
+}
+
+// Every returns a schedule activating the task every interval.
+func Every(interval time.Duration) Schedule {
+	if interval <= 0 {
+		panic("non-positive interval for parallel.Every")
+	}
+	return everySchedule(interval)
+}
+
+type everySchedule time.Duration
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
+
+// OverlapPolicy is an enumeration of modes specifying what happens to the
+// activations of a scheduled task which come while the task is still running.
+type OverlapPolicy int
+
+const (
+	// Skip means the activations missed while the task was running are
+	// dropped, the task runs again at the first activation time in the future.
+	Skip OverlapPolicy = iota
+
+	// Queue means the activations missed while the task was running are
+	// executed one after another as soon as possible.
+	Queue
+)
+
+func (p OverlapPolicy) String() string {
+	switch p {
+	case Skip:
+		return "Skip"
+	case Queue:
+		return "Queue"
+	default:
+		return fmt.Sprintf("invalid OverlapPolicy: %d", p)
+	}
+}
+
+// ScheduleOption is an option of a scheduled task.
+type ScheduleOption func(o *scheduleOptions)
+
+type scheduleOptions struct {
+	jitter     time.Duration
+	fixedDelay bool
+	overlap    OverlapPolicy
+	immediate  bool
+	location   *time.Location
+}
+
+// WithJitter delays each activation by a random duration in the range [0, jitter).
+func WithJitter(jitter time.Duration) ScheduleOption {
+	return func(o *scheduleOptions) {
+		o.jitter = jitter
+	}
+}
+
+// WithFixedDelay computes the next activation time from the moment the previous
+// run finished instead of the moment it was scheduled at. No activations are
+// missed then, so the OverlapPolicy has no effect in this mode.
+func WithFixedDelay() ScheduleOption {
+	return func(o *scheduleOptions) {
+		o.fixedDelay = true
+	}
+}
+
+// WithOverlapPolicy sets the policy applied to the activations missed while the
+// task was running. Default is Skip. It is ignored if WithFixedDelay is used.
+func WithOverlapPolicy(policy OverlapPolicy) ScheduleOption {
+	return func(o *scheduleOptions) {
+		o.overlap = policy
+	}
+}
+
+// WithImmediateStart runs the task once as soon as it is started, before the
+// first activation time defined by the schedule.
+func WithImmediateStart() ScheduleOption {
+	return func(o *scheduleOptions) {
+		o.immediate = true
+	}
+}
+
+// WithLocation sets the time zone used to evaluate the schedule. Default is
+// time.Local.
+func WithLocation(location *time.Location) ScheduleOption {
+	return func(o *scheduleOptions) {
+		o.location = location
+	}
+}
+
+// Periodic returns a task running the given task every interval.
+//
+// The returned task runs until its context is closed or the given task returns
+// an error. Example:
+//
+//	spawn("poller", parallel.Fail, parallel.Periodic(5*time.Second, p.Poll))
+func Periodic(interval time.Duration, task Task, options ...ScheduleOption) Task {
+	return Scheduled(Every(interval), task, options...)
+}
+
+// Cron returns a task running the given task at the times defined by the
+// standard 5-field cron expression. See ParseCron for the supported syntax.
+func Cron(spec string, task Task, options ...ScheduleOption) (Task, error) {
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return nil, err
+	}
+	return Scheduled(schedule, task, options...), nil
+}
+
+// Scheduled returns a task running the given task at the times defined by the
+// schedule.
+//
+// Time is measured by the clock taken from the context, see package clock.
+//
+// Runs never overlap, the given task is always executed in the goroutine of the
+// returned one. The activations which come while the task is still running are
+// handled according to the OverlapPolicy, unless WithFixedDelay is used.
+//
+// The returned task finishes when its context is closed, returning ctx.Err(),
+// or when the given task returns an error, returning that error.
+func Scheduled(schedule Schedule, task Task, options ...ScheduleOption) Task {
+	opts := scheduleOptions{
+		location: time.Local,
+	}
+	for _, o := range options {
+		o(&opts)
+	}
+
+	return func(ctx context.Context) error {
+		clk := clock.Get(ctx)
+		now := clk.Now().In(opts.location)
+		next := now
+		if !opts.immediate {
+			next = schedule.Next(now)
+		}
+		for {
+			if next.IsZero() {
+				// schedule never activates again
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			if err := clock.SleepUntil(ctx, next.Add(opts.jitterDelay())); err != nil {
+				return err
+			}
+			if err := task(ctx); err != nil {
+				return err
+			}
+
+			now = clk.Now().In(opts.location)
+			if opts.fixedDelay {
+				next = schedule.Next(now)
+				continue
+			}
+			next = schedule.Next(next)
+			if opts.overlap == Skip {
+				for !next.IsZero() && !next.After(now) {
+					next = schedule.Next(next)
+				}
+			}
+		}
+	}
+}
+
+func (o scheduleOptions) jitterDelay() time.Duration {
+	if o.jitter <= 0 {
+		return 0
+	}
+	return rand.N(o.jitter)
+}
@@ -0,0 +1,183 @@
+package parallel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/parallel"
+	"github.com/CoreumFoundation/coreum-tools/pkg/parallel/paralleltest"
+)
+
+var scheduleStart = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func TestPeriodic(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	clk := paralleltest.NewFakeClock(scheduleStart)
+	var runs []time.Time
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- parallel.Periodic(time.Second, func(ctx context.Context) error {
+			runs = append(runs, clk.Now())
+			return nil
+		})(clk.Context(ctx))
+	}()
+
+	for range 3 {
+		require.NoError(t, clk.WaitForTimers(ctx, 1))
+		clk.Advance(time.Second)
+	}
+	require.NoError(t, clk.WaitForTimers(ctx, 1))
+	cancel()
+
+	require.ErrorIs(t, <-errCh, context.Canceled)
+	require.Equal(t, []time.Time{
+		scheduleStart.Add(time.Second),
+		scheduleStart.Add(2 * time.Second),
+		scheduleStart.Add(3 * time.Second),
+	}, runs)
+}
+
+func TestPeriodicImmediateStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	clk := paralleltest.NewFakeClock(scheduleStart)
+	var runs []time.Time
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- parallel.Periodic(time.Hour, func(ctx context.Context) error {
+			runs = append(runs, clk.Now())
+			return nil
+		}, parallel.WithImmediateStart())(clk.Context(ctx))
+	}()
+
+	require.NoError(t, clk.WaitForTimers(ctx, 1))
+	clk.Advance(time.Hour)
+	require.NoError(t, clk.WaitForTimers(ctx, 1))
+	cancel()
+
+	require.ErrorIs(t, <-errCh, context.Canceled)
+	require.Equal(t, []time.Time{scheduleStart, scheduleStart.Add(time.Hour)}, runs)
+}
+
+func TestPeriodicError(t *testing.T) {
+	clk := paralleltest.NewFakeClock(scheduleStart)
+	var runs int
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- parallel.Periodic(time.Second, func(ctx context.Context) error {
+			runs++
+			if runs == 2 {
+				return errors.New("oops")
+			}
+			return nil
+		}, parallel.WithFixedDelay(), parallel.WithJitter(time.Second))(clk.Context(t.Context()))
+	}()
+
+	// jitter delays activations by less than a second
+	for range 2 {
+		require.NoError(t, clk.WaitForTimers(t.Context(), 1))
+		clk.Advance(2 * time.Second)
+	}
+
+	require.EqualError(t, <-errCh, "oops")
+	require.Equal(t, 2, runs)
+}
+
+func TestPeriodicSpawned(t *testing.T) {
+	clk := paralleltest.NewFakeClock(scheduleStart)
+	var runs int
+	stop := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- parallel.Run(clk.Context(t.Context()), func(ctx context.Context, spawn parallel.SpawnFn) error {
+			spawn("periodic", parallel.Fail, parallel.Periodic(time.Second, func(ctx context.Context) error {
+				runs++
+				if runs == 3 {
+					close(stop)
+				}
+				return nil
+			}))
+			spawn("stopper", parallel.Exit, func(ctx context.Context) error {
+				<-stop
+				return nil
+			})
+			return nil
+		})
+	}()
+
+	for range 3 {
+		require.NoError(t, clk.WaitForTimers(t.Context(), 1))
+		clk.Advance(time.Second)
+	}
+
+	require.NoError(t, <-errCh)
+	require.Equal(t, 3, runs)
+}
+
+func TestScheduledOverlap(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []parallel.ScheduleOption
+		expected []time.Duration
+	}{
+		{
+			name:     "skip",
+			options:  []parallel.ScheduleOption{parallel.WithOverlapPolicy(parallel.Skip)},
+			expected: []time.Duration{time.Second, 4 * time.Second, 5 * time.Second, 6 * time.Second},
+		},
+		{
+			name:    "queue",
+			options: []parallel.ScheduleOption{parallel.WithOverlapPolicy(parallel.Queue)},
+			expected: []time.Duration{
+				time.Second, 3500 * time.Millisecond, 3500 * time.Millisecond, 4 * time.Second,
+			},
+		},
+		{
+			// overlap policy has no effect if next activation is computed from
+			// the moment the previous run finished
+			name:     "fixed delay",
+			options:  []parallel.ScheduleOption{parallel.WithFixedDelay(), parallel.WithOverlapPolicy(parallel.Queue)},
+			expected: []time.Duration{time.Second, 4500 * time.Millisecond, 5500 * time.Millisecond, 6500 * time.Millisecond},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+
+			clk := paralleltest.NewFakeClock(scheduleStart)
+			var runs []time.Duration
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- parallel.Periodic(time.Second, func(ctx context.Context) error {
+					runs = append(runs, clk.Now().Sub(scheduleStart))
+					if len(runs) == 1 {
+						// first run takes 2.5 seconds
+						clk.Advance(2500 * time.Millisecond)
+					}
+					return nil
+				}, tt.options...)(clk.Context(ctx))
+			}()
+
+			for {
+				require.NoError(t, clk.WaitForTimers(ctx, 1))
+				if len(runs) == len(tt.expected) {
+					break
+				}
+				clk.Advance(500 * time.Millisecond)
+			}
+			cancel()
+
+			require.ErrorIs(t, <-errCh, context.Canceled)
+			require.Equal(t, tt.expected, runs)
+		})
+	}
+}
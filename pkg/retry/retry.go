@@ -5,6 +5,10 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/clock"
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+	"github.com/CoreumFoundation/coreum-tools/pkg/trace"
 )
 
 // Retryable returns retryable error
@@ -30,12 +34,23 @@ func (e RetryableError) Unwrap() error {
 	return e.err
 }
 
-// Do retries running function until it returns non-retryable error
+// Do retries running function until it returns non-retryable error.
+// Delays between attempts are measured by the clock taken from the context.
+// If the context is traced, each attempt is recorded as a span.
 func Do(ctx context.Context, retryAfter time.Duration, fn func() error) error {
+	return DoContext(ctx, retryAfter, func(ctx context.Context) error {
+		return fn()
+	})
+}
+
+// DoContext retries running function like Do, passing it the context of the
+// attempt, so its logs and spans, e.g. the ones of outgoing requests, belong
+// to the span of the attempt.
+func DoContext(ctx context.Context, retryAfter time.Duration, fn func(ctx context.Context) error) error {
 	var r RetryableError
-	for {
+	for attempt := 1; ; attempt++ {
 		var r2 RetryableError
-		if err := fn(); !errors.As(err, &r2) {
+		if err := doAttempt(ctx, attempt, fn); !errors.As(err, &r2) {
 			return err
 		}
 		if errors.Is(r2.err, ctx.Err()) {
@@ -46,13 +61,20 @@ func Do(ctx context.Context, retryAfter time.Duration, fn func() error) error {
 		}
 		r = r2
 
-		select {
-		case <-ctx.Done():
-			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		if err := clock.Sleep(ctx, retryAfter); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
 				return r.err
 			}
-			return errors.WithStack(ctx.Err())
-		case <-time.After(retryAfter):
+			return errors.WithStack(err)
 		}
 	}
 }
+
+func doAttempt(ctx context.Context, attempt int, fn func(ctx context.Context) error) error {
+	ctx, span := trace.StartChild(ctx, "retry attempt")
+	ctx = logger.WithTrace(ctx)
+	span.SetAttribute("attempt", attempt)
+	err := fn(ctx)
+	span.End(err)
+	return err
+}
@@ -0,0 +1,73 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+	"github.com/CoreumFoundation/coreum-tools/pkg/trace"
+)
+
+type recordingExporter struct {
+	spans []trace.SpanData
+}
+
+func (e *recordingExporter) Export(span trace.SpanData) {
+	e.spans = append(e.spans, span)
+}
+
+func TestDoContextPropagatesAttemptSpan(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	exporter := &recordingExporter{}
+	ctx := logger.WithLogger(trace.WithExporter(context.Background(), exporter), zap.New(core))
+	ctx, root := trace.Start(ctx, "root")
+	rootSC, _ := trace.Get(ctx)
+
+	var attempts int
+	err := DoContext(ctx, time.Millisecond, func(ctx context.Context) error {
+		attempts++
+		logger.Get(ctx).Info("attempt")
+		_, span := trace.StartChild(ctx, "request")
+		span.End(nil)
+		if attempts < 2 {
+			return Retryable(errors.New("not yet"))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	root.End(nil)
+
+	attemptSpans := map[trace.SpanID]bool{}
+	var requestParents []trace.SpanID
+	for _, span := range exporter.spans {
+		switch span.Name {
+		case "retry attempt":
+			require.Equal(t, rootSC.SpanID, *span.ParentSpanID)
+			attemptSpans[span.SpanID] = true
+		case "request":
+			requestParents = append(requestParents, *span.ParentSpanID)
+		}
+	}
+	require.Len(t, attemptSpans, 2)
+	require.Len(t, requestParents, 2)
+	for _, parent := range requestParents {
+		require.True(t, attemptSpans[parent])
+	}
+
+	entries := logs.FilterMessage("attempt").All()
+	require.Len(t, entries, 2)
+	for _, entry := range entries {
+		var logged bool
+		for spanID := range attemptSpans {
+			logged = logged || entry.ContextMap()[logger.SpanIDKey] == spanID.String()
+		}
+		require.True(t, logged)
+	}
+}
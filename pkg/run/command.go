@@ -0,0 +1,224 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+	"github.com/CoreumFoundation/coreum-tools/pkg/parallel"
+)
+
+type argsFieldType int
+
+const argsField argsFieldType = iota
+
+// Command is the command of the tool run by ToolWithCommands
+type Command struct {
+	// Name is the name of the command used on the command line
+	Name string
+
+	// Short is the one-line description of the command printed in the help
+	Short string
+
+	// Args describes positional arguments in the help, e.g. [file...]
+	Args string
+
+	// Flags are the flags of the command, parsed before Run is called
+	Flags *pflag.FlagSet
+
+	// Run runs the command, positional arguments are available by Args. If it
+	// is nil, the command only groups its subcommands.
+	Run parallel.Task
+
+	// Commands are the subcommands
+	Commands []*Command
+}
+
+// ToolWithCommands runs tool app executing the command selected by the command
+// line arguments, e.g. "app deploy service --env test" runs subcommand service
+// of command deploy. Logger flags are global, accepted by all the commands.
+// Command completion prints the completion script for bash, zsh or fish.
+//
+// If the command is not found or --help is passed, the help is printed and the
+// app exits with code 2.
+func ToolWithCommands(appName string, commands ...*Command) {
+	appName = filepath.Base(appName)
+	root := &Command{Name: appName, Commands: commands}
+	run(appName, logger.ConfigureWithCLI(logger.ToolDefaultConfig), func(ctx context.Context) error {
+		return root.execute(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	}, parallel.Exit)
+}
+
+// Args returns positional arguments of the command run by ToolWithCommands
+func Args(ctx context.Context) []string {
+	args, _ := ctx.Value(argsField).([]string)
+	return args
+}
+
+func (c *Command) execute(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	root := *c
+	root.Commands = append(c.Commands[:len(c.Commands):len(c.Commands)], completionCommand(&root, stdout))
+
+	global := globalFlags()
+	cmd, path, args := root.find(args, global)
+
+	flags := pflag.NewFlagSet(strings.Join(path, " "), pflag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.SortFlags = false
+	if cmd.Flags != nil {
+		if err := checkFlagConflicts(cmd.Flags, global); err != nil {
+			return errors.Wrapf(err, "invalid flags of command %q", strings.Join(path, " "))
+		}
+		flags.AddFlagSet(cmd.Flags)
+	}
+	flags.AddFlagSet(global)
+	flags.Usage = func() {
+		cmd.printHelp(stderr, path, global)
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return errors.WithStack(err)
+	}
+	if help, err := flags.GetBool("help"); err != nil || help {
+		flags.Usage()
+		return errors.WithStack(pflag.ErrHelp)
+	}
+	if cmd.Run == nil {
+		if flags.NArg() > 0 {
+			fmt.Fprintf(stderr, "unknown command %q for %q\n\n", flags.Arg(0), strings.Join(path, " "))
+		}
+		flags.Usage()
+		return errors.WithStack(pflag.ErrHelp)
+	}
+	return cmd.Run(context.WithValue(ctx, argsField, flags.Args()))
+}
+
+// find returns the command selected by the arguments, its path and the
+// arguments left once the names of the commands are removed
+func (c *Command) find(args []string, global *pflag.FlagSet) (*Command, []string, []string) {
+	cmd := c
+	path := []string{c.Name}
+	rest := make([]string, 0, len(args))
+	positional := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return cmd, path, append(rest, args[i:]...)
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			rest = append(rest, arg)
+			if !strings.Contains(arg, "=") && takesValue(arg, cmd.Flags, global) && i+1 < len(args) {
+				i++
+				rest = append(rest, args[i])
+			}
+		default:
+			if sub := cmd.command(arg); sub != nil && !positional {
+				cmd = sub
+				path = append(path, sub.Name)
+				continue
+			}
+			positional = true
+			rest = append(rest, arg)
+		}
+	}
+	return cmd, path, rest
+}
+
+func (c *Command) command(name string) *Command {
+	for _, cmd := range c.Commands {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// takesValue returns true if the flag requires the value passed in the next argument
+func takesValue(arg string, flagSets ...*pflag.FlagSet) bool {
+	var lookup func(flags *pflag.FlagSet) *pflag.Flag
+	if name, ok := strings.CutPrefix(arg, "--"); ok {
+		lookup = func(flags *pflag.FlagSet) *pflag.Flag {
+			return flags.Lookup(name)
+		}
+	} else {
+		// in the group of shorthands only the last one might take the value
+		shorthand := arg[len(arg)-1:]
+		lookup = func(flags *pflag.FlagSet) *pflag.Flag {
+			return flags.ShorthandLookup(shorthand)
+		}
+	}
+	for _, flags := range flagSets {
+		if flags == nil {
+			continue
+		}
+		if flag := lookup(flags); flag != nil {
+			return flag.NoOptDefVal == ""
+		}
+	}
+	return false
+}
+
+// checkFlagConflicts returns error if the flags of the command are named like
+// the global ones or use the same shorthands, pflag panics on the latter
+func checkFlagConflicts(flags, global *pflag.FlagSet) error {
+	var err error
+	flags.VisitAll(func(f *pflag.Flag) {
+		switch {
+		case err != nil:
+		case global.Lookup(f.Name) != nil:
+			err = errors.Errorf("flag --%s is global", f.Name)
+		case f.Shorthand != "" && global.ShorthandLookup(f.Shorthand) != nil:
+			err = errors.Errorf("shorthand -%s of flag --%s is used by global flag --%s",
+				f.Shorthand, f.Name, global.ShorthandLookup(f.Shorthand).Name)
+		}
+	})
+	return err
+}
+
+func globalFlags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("global", pflag.ContinueOnError)
+	logger.AddFlags(logger.ToolDefaultConfig, flags)
+	flags.BoolP("help", "h", false, "Prints help")
+	return flags
+}
+
+func (c *Command) printHelp(w io.Writer, path []string, global *pflag.FlagSet) {
+	if c.Short != "" {
+		fmt.Fprintf(w, "%s\n\n", c.Short)
+	}
+
+	fmt.Fprintln(w, "Usage:")
+	if c.Run != nil {
+		usage := strings.Join(path, " ") + " [flags]"
+		if c.Args != "" {
+			usage += " " + c.Args
+		}
+		fmt.Fprintf(w, "  %s\n", usage)
+	}
+	if len(c.Commands) > 0 {
+		fmt.Fprintf(w, "  %s [command]\n", strings.Join(path, " "))
+
+		fmt.Fprintln(w, "\nCommands:")
+		tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		for _, cmd := range c.Commands {
+			fmt.Fprintf(tw, "  %s\t%s\n", cmd.Name, cmd.Short)
+		}
+		_ = tw.Flush()
+	}
+
+	if c.Flags != nil && c.Flags.HasFlags() {
+		fmt.Fprintf(w, "\nFlags:\n%s", c.Flags.FlagUsages())
+	}
+	fmt.Fprintf(w, "\nGlobal Flags:\n%s", global.FlagUsages())
+	if len(c.Commands) > 0 {
+		fmt.Fprintf(w, "\nUse \"%s [command] --help\" for more information about a command.\n", strings.Join(path, " "))
+	}
+}
@@ -0,0 +1,191 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+)
+
+type testTool struct {
+	root   *Command
+	env    *string
+	force  *bool
+	called string
+	args   []string
+}
+
+func newTestTool() *testTool {
+	tool := &testTool{}
+
+	deployFlags := pflag.NewFlagSet("deploy", pflag.ContinueOnError)
+	tool.env = deployFlags.StringP("env", "e", "dev", "Environment to deploy to")
+	tool.force = deployFlags.Bool("force", false, "Deploys even if checks fail")
+
+	run := func(name string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			tool.called = name
+			tool.args = Args(ctx)
+			return nil
+		}
+	}
+
+	tool.root = &Command{
+		Name: "tool",
+		Commands: []*Command{
+			{
+				Name:  "deploy",
+				Short: "Deploys components",
+				Commands: []*Command{
+					{Name: "service", Short: "Deploys service", Args: "[name...]", Flags: deployFlags, Run: run("deploy service")},
+					{Name: "contract", Short: "Deploys contract", Run: run("deploy contract")},
+				},
+			},
+			{Name: "version", Short: "Prints version", Run: run("version")},
+		},
+	}
+	return tool
+}
+
+func (tool *testTool) execute(args ...string) (string, string, error) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err := tool.root.execute(context.Background(), args, stdout, stderr)
+	return stdout.String(), stderr.String(), err
+}
+
+func TestCommandDispatch(t *testing.T) {
+	tool := newTestTool()
+	_, _, err := tool.execute("version")
+	require.NoError(t, err)
+	require.Equal(t, "version", tool.called)
+	require.Empty(t, tool.args)
+
+	tool = newTestTool()
+	_, _, err = tool.execute("--log-format", "json", "deploy", "-v", "service", "--env", "prod", "--force", "api", "service", "--", "--raw")
+	require.NoError(t, err)
+	require.Equal(t, "deploy service", tool.called)
+	require.Equal(t, "prod", *tool.env)
+	require.True(t, *tool.force)
+	// names of commands are positional arguments once positional argument is passed
+	require.Equal(t, []string{"api", "service", "--raw"}, tool.args)
+
+	tool = newTestTool()
+	_, _, err = tool.execute("deploy", "service", "-ve", "test")
+	require.NoError(t, err)
+	require.Equal(t, "test", *tool.env)
+	require.Empty(t, tool.args)
+}
+
+func TestCommandHelp(t *testing.T) {
+	tool := newTestTool()
+	_, stderr, err := tool.execute("deploy", "service", "--help")
+	require.ErrorIs(t, err, pflag.ErrHelp)
+	require.Empty(t, tool.called)
+	require.Contains(t, stderr, "Deploys service\n\nUsage:\n  tool deploy service [flags] [name...]\n")
+	require.Contains(t, stderr, "\nFlags:\n  -e, --env string")
+	require.Contains(t, stderr, "\nGlobal Flags:\n")
+	require.Contains(t, stderr, "--log-format string")
+
+	// groups print help
+	_, stderr, err = tool.execute("deploy")
+	require.ErrorIs(t, err, pflag.ErrHelp)
+	require.Contains(t, stderr, "Usage:\n  tool deploy [command]\n\nCommands:\n  service    Deploys service\n  contract   Deploys contract\n")
+
+	_, stderr, err = tool.execute()
+	require.ErrorIs(t, err, pflag.ErrHelp)
+	require.Contains(t, stderr, "  completion")
+
+	_, stderr, err = tool.execute("deploy", "unknown")
+	require.ErrorIs(t, err, pflag.ErrHelp)
+	require.True(t, strings.HasPrefix(stderr, `unknown command "unknown" for "tool deploy"`))
+
+	_, _, err = tool.execute("version", "--unknown")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, pflag.ErrHelp)
+}
+
+func TestCompletion(t *testing.T) {
+	tool := newTestTool()
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		stdout, _, err := tool.execute("completion", shell)
+		require.NoError(t, err)
+		require.Contains(t, stdout, "deploy", shell)
+		require.Contains(t, stdout, "env", shell)
+		require.Contains(t, stdout, "log-format", shell)
+	}
+
+	_, _, err := tool.execute("completion", "powershell")
+	require.Error(t, err)
+	_, _, err = tool.execute("completion")
+	require.Error(t, err)
+}
+
+func TestBashCompletion(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	// names are not expanded by bash
+	const special = "$HOME`id`\\it's"
+	tool := newTestTool()
+	tool.root.Commands = append(tool.root.Commands, &Command{
+		Name:     special,
+		Commands: []*Command{{Name: "sub", Run: func(ctx context.Context) error { return nil }}},
+	})
+	script, _, err := tool.execute("completion", "bash")
+	require.NoError(t, err)
+
+	complete := func(words ...string) []string {
+		args := append([]string{"-c", script + `
+COMP_WORDS=("$@")
+COMP_CWORD=$(($# - 1))
+__tool_complete
+printf '%s\n' "${COMPREPLY[@]}"
+`, "bash"}, words...)
+		cmd := exec.Command(bash, args...)
+		out, err := cmd.Output()
+		require.NoError(t, err)
+		return strings.Fields(string(out))
+	}
+
+	require.Equal(t, []string{"deploy"}, complete("tool", "de"))
+	require.Equal(t, []string{"service"}, complete("tool", "deploy", "s"))
+	require.Equal(t, []string{"--env"}, complete("tool", "-v", "deploy", "service", "--en"))
+	require.Contains(t, complete("tool", "version", "--"), "--log-format")
+	require.NotContains(t, complete("tool", "version", "--"), "--env")
+	require.Equal(t, []string{special}, complete("tool", "$"))
+	require.Equal(t, []string{"sub"}, complete("tool", special, "s"))
+}
+
+func TestCommandFlagConflicts(t *testing.T) {
+	for _, flag := range []string{"verbose", "log-format"} {
+		flags := pflag.NewFlagSet("conflict", pflag.ContinueOnError)
+		flags.Bool(flag, false, "")
+		tool := newTestTool()
+		tool.root.Commands = append(tool.root.Commands, &Command{
+			Name:  "conflict",
+			Flags: flags,
+			Run:   func(ctx context.Context) error { return nil },
+		})
+		_, _, err := tool.execute("conflict")
+		require.Error(t, err, flag)
+	}
+
+	// shorthand conflicts make pflag panic
+	flags := pflag.NewFlagSet("conflict", pflag.ContinueOnError)
+	flags.BoolP("vet", "v", false, "")
+	tool := newTestTool()
+	tool.root.Commands = append(tool.root.Commands, &Command{
+		Name:  "conflict",
+		Flags: flags,
+		Run:   func(ctx context.Context) error { return nil },
+	})
+	_, _, err := tool.execute("conflict")
+	require.ErrorContains(t, err, "shorthand -v")
+}
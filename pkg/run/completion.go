@@ -0,0 +1,213 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+var completionShells = map[string]func(w io.Writer, root *Command){
+	"bash": writeBashCompletion,
+	"zsh":  writeZshCompletion,
+	"fish": writeFishCompletion,
+}
+
+// completionCommand returns the command printing the completion script of the root command
+func completionCommand(root *Command, stdout io.Writer) *Command {
+	shells := make([]string, 0, len(completionShells))
+	for shell := range completionShells {
+		shells = append(shells, shell)
+	}
+	sort.Strings(shells)
+
+	return &Command{
+		Name:  "completion",
+		Short: "Prints shell completion script, e.g. source <(" + root.Name + " completion bash)",
+		Args:  strings.Join(shells, " | "),
+		Run: func(ctx context.Context) error {
+			args := Args(ctx)
+			if len(args) != 1 {
+				return errors.Errorf("expected one shell: %s", strings.Join(shells, " | "))
+			}
+			write, ok := completionShells[args[0]]
+			if !ok {
+				return errors.Errorf("unsupported shell %q, expected: %s", args[0], strings.Join(shells, " | "))
+			}
+			write(stdout, root)
+			return nil
+		},
+	}
+}
+
+// completionEntry describes completions offered once the command path is typed
+type completionEntry struct {
+	path     string
+	commands []*Command
+	flags    []*pflag.Flag
+}
+
+// completionEntries returns entries of all the commands in the tree, path of
+// the root command is empty
+func completionEntries(root *Command) []completionEntry {
+	global := globalFlags()
+	var entries []completionEntry
+	var walk func(cmd *Command, path string)
+	walk = func(cmd *Command, path string) {
+		entry := completionEntry{path: path, commands: cmd.Commands}
+		if cmd.Flags != nil {
+			cmd.Flags.VisitAll(func(f *pflag.Flag) {
+				entry.flags = append(entry.flags, f)
+			})
+		}
+		global.VisitAll(func(f *pflag.Flag) {
+			entry.flags = append(entry.flags, f)
+		})
+		entries = append(entries, entry)
+
+		for _, sub := range cmd.Commands {
+			subPath := sub.Name
+			if path != "" {
+				subPath = path + " " + sub.Name
+			}
+			walk(sub, subPath)
+		}
+	}
+	walk(root, "")
+	return entries
+}
+
+func completionFuncName(root *Command) string {
+	return "__" + strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, root.Name)
+}
+
+func writeBashCompletion(w io.Writer, root *Command) {
+	entries := completionEntries(root)
+	name := completionFuncName(root)
+
+	paths := make([]string, 0, len(entries))
+	for _, entry := range entries[1:] {
+		paths = append(paths, bashQuote(entry.path))
+	}
+
+	fmt.Fprintf(w, "# bash completion for %s\n", root.Name)
+	fmt.Fprintf(w, "%s_complete() {\n", name)
+	fmt.Fprintln(w, `    local cur="${COMP_WORDS[COMP_CWORD]}" path="" word i`)
+	fmt.Fprintln(w, `    for ((i = 1; i < COMP_CWORD; i++)); do`)
+	fmt.Fprintln(w, `        word="${COMP_WORDS[i]}"`)
+	if len(paths) > 0 {
+		fmt.Fprintln(w, `        case "${path:+$path }$word" in`)
+		fmt.Fprintf(w, "            %s) path=\"${path:+$path }$word\" ;;\n", strings.Join(paths, "|"))
+		fmt.Fprintln(w, `        esac`)
+	}
+	fmt.Fprintln(w, `    done`)
+	fmt.Fprintln(w, `    local words=""`)
+	fmt.Fprintln(w, `    case "$path" in`)
+	for _, entry := range entries {
+		words := make([]string, 0, len(entry.commands)+len(entry.flags))
+		for _, cmd := range entry.commands {
+			words = append(words, cmd.Name)
+		}
+		for _, f := range entry.flags {
+			words = append(words, "--"+f.Name)
+			if f.Shorthand != "" {
+				words = append(words, "-"+f.Shorthand)
+			}
+		}
+		// words are expanded by compgen, so they are escaped too
+		for i, word := range words {
+			words[i] = bashEscape(word)
+		}
+		fmt.Fprintf(w, "        %s) words=%s ;;\n", bashQuote(entry.path), bashQuote(strings.Join(words, " ")))
+	}
+	fmt.Fprintln(w, `    esac`)
+	fmt.Fprintln(w, `    COMPREPLY=($(compgen -W "$words" -- "$cur"))`)
+	fmt.Fprintln(w, "}")
+	fmt.Fprintf(w, "complete -o default -F %s_complete %s\n", name, bashQuote(root.Name))
+}
+
+func writeZshCompletion(w io.Writer, root *Command) {
+	fmt.Fprintf(w, "#compdef %s\n", root.Name)
+	fmt.Fprintln(w, "autoload -U +X bashcompinit && bashcompinit")
+	writeBashCompletion(w, root)
+}
+
+func writeFishCompletion(w io.Writer, root *Command) {
+	entries := completionEntries(root)
+	name := completionFuncName(root)
+
+	fmt.Fprintf(w, "# fish completion for %s\n", root.Name)
+	fmt.Fprintf(w, "function %s_path\n", name)
+	fmt.Fprintln(w, "    set -l path ''")
+	fmt.Fprintln(w, "    for word in (commandline -opc)[2..-1]")
+	fmt.Fprintln(w, "        set -l next $word")
+	fmt.Fprintln(w, "        if test -n \"$path\"")
+	fmt.Fprintln(w, "            set next \"$path $word\"")
+	fmt.Fprintln(w, "        end")
+	fmt.Fprint(w, "        if contains -- \"$next\"")
+	for _, entry := range entries[1:] {
+		fmt.Fprintf(w, " %s", fishQuote(entry.path))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "            set path $next")
+	fmt.Fprintln(w, "        end")
+	fmt.Fprintln(w, "    end")
+	fmt.Fprintln(w, "    echo $path")
+	fmt.Fprintln(w, "end")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "function %s_at\n", name)
+	fmt.Fprintf(w, "    set -l path (%s_path)\n", name)
+	fmt.Fprintln(w, "    test \"$path\" = \"$argv[1]\"")
+	fmt.Fprintln(w, "end")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "complete -c %s -f\n", root.Name)
+	for _, entry := range entries {
+		condition := fishQuote(name + "_at " + fishQuote(entry.path))
+		for _, cmd := range entry.commands {
+			fmt.Fprintf(w, "complete -c %s -n %s -a %s -d %s\n",
+				root.Name, condition, fishQuote(cmd.Name), fishQuote(cmd.Short))
+		}
+		for _, f := range entry.flags {
+			line := fmt.Sprintf("complete -c %s -n %s -l %s", root.Name, condition, fishQuote(f.Name))
+			if f.Shorthand != "" {
+				line += " -s " + fishQuote(f.Shorthand)
+			}
+			if f.NoOptDefVal == "" {
+				line += " -r"
+			}
+			fmt.Fprintf(w, "%s -d %s\n", line, fishQuote(f.Usage))
+		}
+	}
+}
+
+// bashQuote returns the string in single quotes escaped for bash, nothing is
+// expanded inside them
+func bashQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// bashEscape escapes the characters special to bash with backslashes
+func bashEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=,:@+%", r)) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// fishQuote returns the string in single quotes escaped for fish
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package run
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+)
+
+// handleLevelSignals does nothing because SIGUSR1 and SIGUSR2 are not available
+func handleLevelSignals(ctx context.Context, _ *zap.Logger, _ *logger.Levels) error {
+	<-ctx.Done()
+	return nil
+}
@@ -0,0 +1,38 @@
+//go:build unix
+
+package run
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+)
+
+// handleLevelSignals turns on debug logging on SIGUSR1 and restores the initial level on SIGUSR2
+func handleLevelSignals(ctx context.Context, log *zap.Logger, levels *logger.Levels) error {
+	initial := levels.Level()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGUSR1, syscall.SIGUSR2)
+	defer signal.Stop(sigs)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case sig := <-sigs:
+			level := initial
+			if sig == syscall.SIGUSR1 {
+				level = zapcore.DebugLevel
+			}
+			levels.SetLevel(level)
+			log.Info("Log level changed", zap.Stringer("signal", sig), zap.Stringer("level", level))
+		}
+	}
+}
@@ -3,6 +3,7 @@ package run
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -13,6 +14,7 @@ import (
 	"github.com/spf13/pflag"
 	"go.uber.org/zap"
 
+	"github.com/CoreumFoundation/coreum-tools/pkg/config"
 	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
 	"github.com/CoreumFoundation/coreum-tools/pkg/parallel"
 )
@@ -22,22 +24,88 @@ type AppRunner func(appFunc parallel.Task)
 
 var mu sync.Mutex
 
-// Service runs service app
+// Service runs service app.
+//
+// Logger levels might be changed at runtime: SIGUSR1 turns on debug logging,
+// SIGUSR2 restores the initial level. Levels are available to the app by
+// logger.GetLevels, e.g. to expose them over HTTP.
 func Service(appName string, appFunc parallel.Task) {
-	run(filepath.Base(appName), logger.ServiceDefaultConfig, appFunc, parallel.Fail)
+	run(filepath.Base(appName), logger.ConfigureWithCLI(logger.ServiceDefaultConfig), appFunc, parallel.Fail)
+}
+
+// ServiceWithConfig runs service app like Service, passing it the config loaded
+// by config.Load from command line flags, environment variables prefixed with
+// the app name, e.g. MY_APP_LISTEN_ADDRESS, and the file passed in --config flag.
+// Fields of defaultConfig, which must be a struct, are used as defaults.
+//
+// Logger flags are parsed together with the config ones, so config fields must
+// not be bound to the same flags, e.g. --verbose. Logger settings are also taken
+// from environment variables with the same prefix, e.g. MY_APP_LOG_FORMAT, and
+// the "log" section of the config file.
+func ServiceWithConfig[T any](appName string, defaultConfig T, appFunc func(ctx context.Context, config T) error) {
+	appName = filepath.Base(appName)
+	cfg := defaultConfig
+	loggerConfig, err := loadConfig(appName, &cfg, os.Args[1:], os.LookupEnv)
+	switch {
+	case err == nil:
+	case errors.Is(err, pflag.ErrHelp):
+		os.Exit(2)
+	default:
+		fmt.Fprintf(os.Stderr, "Loading config failed: %+v\n", err)
+		os.Exit(1)
+	}
+
+	run(appName, loggerConfig, func(ctx context.Context) error {
+		return appFunc(ctx, cfg)
+	}, parallel.Fail)
+}
+
+// loadConfig parses the arguments once, filling the config and returning the
+// logger config
+func loadConfig(
+	appName string,
+	cfg interface{},
+	args []string,
+	lookupEnv func(key string) (string, bool),
+) (logger.Config, error) {
+	envPrefix := config.EnvPrefix(appName)
+	var flags *pflag.FlagSet
+	if err := config.Load(cfg, args,
+		config.WithName(appName),
+		config.WithEnvPrefix(envPrefix),
+		config.WithLookupEnv(lookupEnv),
+		config.WithFlags(func(fs *pflag.FlagSet) {
+			logger.AddFlags(logger.ServiceDefaultConfig, fs)
+			flags = fs
+		}),
+	); err != nil {
+		return logger.Config{}, err
+	}
+
+	configFile, err := flags.GetString(config.FileFlag)
+	if err != nil {
+		return logger.Config{}, errors.WithStack(err)
+	}
+	return logger.ConfigureWithFlags(logger.ServiceDefaultConfig, flags, logger.Sources{
+		EnvPrefix:  envPrefix,
+		ConfigFile: configFile,
+		LookupEnv:  lookupEnv,
+	})
 }
 
 // Tool runs tool app
 func Tool(appName string, appFunc parallel.Task) {
-	run(filepath.Base(appName), logger.ToolDefaultConfig, appFunc, parallel.Exit)
+	run(filepath.Base(appName), logger.ConfigureWithCLI(logger.ToolDefaultConfig), appFunc, parallel.Exit)
 }
 
 func run(appName string, loggerConfig logger.Config, appFunc parallel.Task, exit parallel.OnExit) {
-	log := logger.New(logger.ConfigureWithCLI(loggerConfig))
+	log, levels := logger.NewWithLevels(loggerConfig)
 	if appName != "" && appName != "." {
 		log = log.Named(appName)
 	}
-	ctx := logger.WithLogger(context.Background(), log)
+	ctx := logger.WithLevels(logger.WithLogger(context.Background(), log), levels)
+	logger.SetDefault(log)
+	slog.SetDefault(slog.New(logger.NewSlogHandler(log)))
 
 	err := parallel.Run(ctx, func(ctx context.Context, spawn parallel.SpawnFn) error {
 		spawn("", exit, func(ctx context.Context) error {
@@ -62,16 +130,25 @@ func run(appName string, loggerConfig logger.Config, appFunc parallel.Task, exit
 			}
 			return nil
 		})
+		spawn("levelSignals", parallel.Continue, func(ctx context.Context) error {
+			return handleLevelSignals(ctx, log, levels)
+		})
 		return nil
 	})
 
+	exitCode := 0
 	switch {
 	case err == nil:
 	case errors.Is(err, ctx.Err()):
 	case errors.Is(err, pflag.ErrHelp):
-		os.Exit(2)
+		exitCode = 2
 	default:
 		log.Error(fmt.Sprintf("Application returned error: %+v", err))
-		os.Exit(1)
+		exitCode = 1
+	}
+
+	_ = logger.Close(log)
+	if exitCode != 0 {
+		os.Exit(exitCode)
 	}
 }
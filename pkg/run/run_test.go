@@ -0,0 +1,62 @@
+package run
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+)
+
+func TestLoadConfig(t *testing.T) {
+	type appConfig struct {
+		Listen string `default:":8080"`
+		Peers  []string
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+listen: ":9090"
+peers: ["peer1", "peer2"]
+log:
+  format: yaml
+  level: error
+  levels: parallel=warn
+`), 0o600))
+
+	env := map[string]string{
+		"MY_APP_CONFIG":    path,
+		"MY_APP_LOG_LEVEL": "warn",
+		"LOG_LEVEL":        "debug",
+	}
+	lookupEnv := func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+
+	cfg := appConfig{}
+	loggerConfig, err := loadConfig("my-app", &cfg, []string{"--peers", "peer3", "--log-color", "always"}, lookupEnv)
+	require.NoError(t, err)
+	require.Equal(t, appConfig{Listen: ":9090", Peers: []string{"peer3"}}, cfg)
+	require.Equal(t, logger.FormatYAML, loggerConfig.Format)
+	require.Equal(t, zapcore.WarnLevel, loggerConfig.Level)
+	require.False(t, loggerConfig.Verbose)
+	require.Equal(t, map[string]zapcore.Level{"parallel": zapcore.WarnLevel}, loggerConfig.LevelOverrides)
+	require.Equal(t, logger.ColorAlways, loggerConfig.Color)
+
+	// defaults of the service
+	loggerConfig, err = loadConfig("my-app", &appConfig{}, nil, lookupEnv)
+	require.NoError(t, err)
+	require.Equal(t, zapcore.WarnLevel, loggerConfig.Level)
+	loggerConfig, err = loadConfig("my-app", &appConfig{}, nil, func(string) (string, bool) { return "", false })
+	require.NoError(t, err)
+	require.Equal(t, logger.FormatJSON, loggerConfig.Format)
+	require.Equal(t, zapcore.DebugLevel, loggerConfig.Level)
+
+	// unknown flags are rejected by the single parser
+	_, err = loadConfig("my-app", &appConfig{}, []string{"--unknown"}, lookupEnv)
+	require.Error(t, err)
+}
@@ -0,0 +1,76 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type exporterFieldType int
+
+const exporterField exporterFieldType = iota
+
+var _ Exporter = &FileExporter{}
+
+// Exporter receives finished spans. It must be safe for concurrent use.
+type Exporter interface {
+	Export(span SpanData)
+}
+
+// WithExporter adds exporter to context, so spans started from it are recorded
+func WithExporter(ctx context.Context, exporter Exporter) context.Context {
+	return context.WithValue(ctx, exporterField, exporter)
+}
+
+func getExporter(ctx context.Context) Exporter {
+	exporter, _ := ctx.Value(exporterField).(Exporter)
+	return exporter
+}
+
+// FileExporter writes spans to the file as JSON lines
+type FileExporter struct {
+	mu      sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+	err     error
+}
+
+// NewFileExporter returns exporter appending spans to the file, which is
+// created if it doesn't exist
+func NewFileExporter(path string) (*FileExporter, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &FileExporter{
+		file:    file,
+		encoder: json.NewEncoder(file),
+	}, nil
+}
+
+// Export writes the span to the file. Errors are reported by Close.
+func (e *FileExporter) Export(span SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.err != nil {
+		return
+	}
+	if err := e.encoder.Encode(span); err != nil {
+		e.err = errors.Wrapf(err, "exporting span %s failed", span.Name)
+	}
+}
+
+// Close closes the file and returns the first error of writing spans
+func (e *FileExporter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.file.Close(); err != nil && e.err == nil {
+		e.err = errors.WithStack(err)
+	}
+	return e.err
+}
@@ -0,0 +1,130 @@
+package trace
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/clock"
+)
+
+// Status is the status of the finished span
+type Status string
+
+const (
+	// StatusOK is the status of the span finished without error
+	StatusOK Status = "ok"
+
+	// StatusError is the status of the span finished with error
+	StatusError Status = "error"
+)
+
+// SpanData is the finished span passed to the exporter
+type SpanData struct {
+	Name         string                 `json:"name"`
+	TraceID      TraceID                `json:"trace_id"`
+	SpanID       SpanID                 `json:"span_id"`
+	ParentSpanID *SpanID                `json:"parent_span_id,omitempty"`
+	Start        time.Time              `json:"start"`
+	End          time.Time              `json:"end"`
+	Duration     float64                `json:"duration"`
+	Status       Status                 `json:"status"`
+	Error        string                 `json:"error,omitempty"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// Span is the started span. Nil span is valid, all its methods do nothing.
+type Span struct {
+	exporter Exporter
+	clock    clock.Clock
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// Start returns context carrying a new span. The span is the child of the span
+// taken from context, or the root of a new trace if context contains none.
+//
+// The span is recorded only if context contains the exporter and the trace is
+// sampled, otherwise nil span is returned and only IDs are propagated. End must
+// be called once the operation finishes.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	parent, traced := Get(ctx)
+	sc := newSpanContext(ctx)
+	ctx = WithSpanContext(ctx, sc)
+
+	exporter := getExporter(ctx)
+	if exporter == nil || sc.Flags&FlagSampled == 0 {
+		return ctx, nil
+	}
+
+	span := &Span{
+		exporter: exporter,
+		clock:    clock.Get(ctx),
+		data: SpanData{
+			Name:    name,
+			TraceID: sc.TraceID,
+			SpanID:  sc.SpanID,
+		},
+	}
+	if traced {
+		span.data.ParentSpanID = &parent.SpanID
+	}
+	span.data.Start = span.clock.Now()
+	return ctx, span
+}
+
+// StartChild starts a new child of the span taken from context, like Start.
+// Context and nil span are returned if context isn't traced.
+func StartChild(ctx context.Context, name string) (context.Context, *Span) {
+	if _, ok := Get(ctx); !ok {
+		return ctx, nil
+	}
+	return Start(ctx, name)
+}
+
+// SetAttribute sets the attribute of the span. Attributes set after End are
+// ignored, because the span has been passed to the exporter.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return
+	}
+	if s.data.Attributes == nil {
+		s.data.Attributes = map[string]interface{}{}
+	}
+	s.data.Attributes[key] = value
+}
+
+// End finishes the span and passes it to the exporter. The span status is set
+// to error if err is not nil. Only the first call has an effect.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = s.clock.Now()
+	s.data.Duration = s.data.End.Sub(s.data.Start).Seconds()
+	s.data.Status = StatusOK
+	if err != nil {
+		s.data.Status = StatusError
+		s.data.Error = err.Error()
+	}
+	data := s.data
+	s.mu.Unlock()
+
+	s.exporter.Export(data)
+}
@@ -0,0 +1,130 @@
+package trace
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/clock"
+)
+
+type memoryExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+func (e *memoryExporter) Export(span SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = append(e.spans, span)
+}
+
+type stepClock struct {
+	clock.Clock
+	now time.Time
+}
+
+func (c *stepClock) Now() time.Time {
+	c.now = c.now.Add(time.Second)
+	return c.now
+}
+
+func TestSpans(t *testing.T) {
+	exporter := &memoryExporter{}
+	start := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	ctx := clock.WithClock(WithExporter(context.Background(), exporter), &stepClock{now: start})
+
+	rootCtx, root := Start(ctx, "root")
+	_, child := StartChild(rootCtx, "child")
+	child.SetAttribute("attempt", 1)
+	child.End(errors.New("failed"))
+	child.End(nil)
+	// exported attributes are not modified
+	child.SetAttribute("attempt", 2)
+	child.SetAttribute("late", true)
+	root.End(nil)
+
+	rootSC, _ := Get(rootCtx)
+	require.Len(t, exporter.spans, 2)
+	require.Equal(t, SpanData{
+		Name:         "child",
+		TraceID:      rootSC.TraceID,
+		SpanID:       exporter.spans[0].SpanID,
+		ParentSpanID: &rootSC.SpanID,
+		Start:        start.Add(2 * time.Second),
+		End:          start.Add(3 * time.Second),
+		Duration:     1,
+		Status:       StatusError,
+		Error:        "failed",
+		Attributes:   map[string]interface{}{"attempt": 1},
+	}, exporter.spans[0])
+	require.Equal(t, SpanData{
+		Name:     "root",
+		TraceID:  rootSC.TraceID,
+		SpanID:   rootSC.SpanID,
+		Start:    start.Add(time.Second),
+		End:      start.Add(4 * time.Second),
+		Duration: 3,
+		Status:   StatusOK,
+	}, exporter.spans[1])
+
+	// not sampled traces are not recorded
+	ctx = WithSpanContext(ctx, SpanContext{TraceID: rootSC.TraceID, SpanID: rootSC.SpanID})
+	_, span := StartChild(ctx, "not sampled")
+	require.Nil(t, span)
+	span.SetAttribute("key", "value")
+	span.End(nil)
+	require.Len(t, exporter.spans, 2)
+}
+
+func TestFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.jsonl")
+	exporter, err := NewFileExporter(path)
+	require.NoError(t, err)
+
+	ctx, root := Start(WithExporter(context.Background(), exporter), "root")
+	_, child := StartChild(ctx, "child")
+	child.SetAttribute("http.status_code", 500)
+	child.End(errors.New("failed"))
+	root.End(nil)
+	require.NoError(t, exporter.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var spans []map[string]interface{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		span := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &span))
+		spans = append(spans, span)
+	}
+	require.NoError(t, scanner.Err())
+	require.Len(t, spans, 2)
+
+	require.Equal(t, "child", spans[0]["name"])
+	require.Equal(t, "error", spans[0]["status"])
+	require.Equal(t, "failed", spans[0]["error"])
+	require.Equal(t, map[string]interface{}{"http.status_code": float64(500)}, spans[0]["attributes"])
+	require.Equal(t, spans[1]["span_id"], spans[0]["parent_span_id"])
+	require.Equal(t, spans[1]["trace_id"], spans[0]["trace_id"])
+	require.Len(t, spans[0]["trace_id"], 32)
+
+	require.Equal(t, "root", spans[1]["name"])
+	require.Equal(t, "ok", spans[1]["status"])
+	require.NotContains(t, spans[1], "parent_span_id")
+	require.NotContains(t, spans[1], "error")
+	require.Contains(t, spans[1], "start")
+	require.Contains(t, spans[1], "end")
+	require.Contains(t, spans[1], "duration")
+}
@@ -0,0 +1,185 @@
+// Package trace implements lightweight W3C trace context propagation, see
+// https://www.w3.org/TR/trace-context/. It doesn't require any collector, trace
+// and span IDs are carried by contexts, included in logs and sent to other
+// services in the traceparent header. Spans are recorded with timings and
+// errors if the exporter is added to context, see WithExporter.
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type spanFieldType int
+
+const spanField spanFieldType = iota
+
+const (
+	// HeaderTraceparent is the HTTP header carrying trace and parent span IDs
+	HeaderTraceparent = "traceparent"
+
+	// HeaderTracestate is the HTTP header carrying vendor-specific trace data
+	HeaderTracestate = "tracestate"
+)
+
+// FlagSampled is the trace flag telling that the caller may have recorded trace data
+const FlagSampled byte = 0x01
+
+const traceparentVersion = "00"
+
+// TraceID identifies the trace
+type TraceID [16]byte
+
+// String returns the trace ID in the hex form
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// MarshalText returns the trace ID in the hex form
+func (id TraceID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// IsValid returns true if trace ID is not all zeros
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// SpanID identifies the span within the trace
+type SpanID [8]byte
+
+// String returns the span ID in the hex form
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// MarshalText returns the span ID in the hex form
+func (id SpanID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// IsValid returns true if span ID is not all zeros
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// SpanContext identifies the span and the trace it belongs to
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Flags   byte
+
+	// State is the value of tracestate header passed unchanged to other services
+	State string
+}
+
+// IsValid returns true if both trace and span IDs are valid
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Traceparent returns the value of traceparent header identifying the span
+func (sc SpanContext) Traceparent() string {
+	return traceparentVersion + "-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + hex.EncodeToString([]byte{sc.Flags})
+}
+
+// ParseTraceparent parses the value of traceparent header
+func ParseTraceparent(traceparent string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 {
+		return SpanContext{}, errors.Errorf("invalid traceparent %q", traceparent)
+	}
+	version, err := decodeHex(parts[0], 1)
+	if err != nil || version[0] == 0xff || (parts[0] == traceparentVersion && len(parts) != 4) {
+		return SpanContext{}, errors.Errorf("invalid traceparent version in %q", traceparent)
+	}
+
+	var sc SpanContext
+	traceID, err := decodeHex(parts[1], len(sc.TraceID))
+	if err != nil {
+		return SpanContext{}, errors.Errorf("invalid trace ID in %q", traceparent)
+	}
+	spanID, err := decodeHex(parts[2], len(sc.SpanID))
+	if err != nil {
+		return SpanContext{}, errors.Errorf("invalid span ID in %q", traceparent)
+	}
+	flags, err := decodeHex(parts[3], 1)
+	if err != nil {
+		return SpanContext{}, errors.Errorf("invalid trace flags in %q", traceparent)
+	}
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	sc.Flags = flags[0]
+	if !sc.IsValid() {
+		return SpanContext{}, errors.Errorf("invalid traceparent %q, IDs must not be zero", traceparent)
+	}
+	return sc, nil
+}
+
+// decodeHex decodes lowercase hex string of n bytes
+func decodeHex(s string, n int) ([]byte, error) {
+	if len(s) != 2*n || strings.ToLower(s) != s {
+		return nil, errors.Errorf("expected %d lowercase hex digits", 2*n)
+	}
+	res, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return res, nil
+}
+
+// WithSpanContext adds span context to context
+func WithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanField, sc)
+}
+
+// Get gets span context from context, false is returned if context contains none
+func Get(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanField).(SpanContext)
+	return sc, ok && sc.IsValid()
+}
+
+func newSpanContext(ctx context.Context) SpanContext {
+	sc, ok := Get(ctx)
+	if !ok {
+		sc = SpanContext{Flags: FlagSampled}
+		for !sc.TraceID.IsValid() {
+			_, _ = rand.Read(sc.TraceID[:])
+		}
+	}
+	sc.SpanID = SpanID{}
+	for !sc.SpanID.IsValid() {
+		_, _ = rand.Read(sc.SpanID[:])
+	}
+	return sc
+}
+
+// Inject sets traceparent and tracestate headers identifying the span taken
+// from context. Headers are not modified if context isn't traced.
+func Inject(ctx context.Context, header http.Header) {
+	sc, ok := Get(ctx)
+	if !ok {
+		return
+	}
+	header.Set(HeaderTraceparent, sc.Traceparent())
+	if sc.State != "" {
+		header.Set(HeaderTracestate, sc.State)
+	}
+}
+
+// Extract returns context carrying the span identified by traceparent and
+// tracestate headers, so spans started from it continue the trace of the
+// caller. Context is returned unchanged if headers are missing or invalid.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	sc, err := ParseTraceparent(header.Get(HeaderTraceparent))
+	if err != nil {
+		return ctx
+	}
+	sc.State = strings.Join(header.Values(HeaderTracestate), ",")
+	return WithSpanContext(ctx, sc)
+}
@@ -0,0 +1,89 @@
+package trace
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	sc, err := ParseTraceparent(traceparent)
+	require.NoError(t, err)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	require.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+	require.Equal(t, FlagSampled, sc.Flags)
+	require.Equal(t, traceparent, sc.Traceparent())
+
+	// future versions might append fields
+	_, err = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+	require.NoError(t, err)
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-zz",
+	} {
+		_, err := ParseTraceparent(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestStart(t *testing.T) {
+	ctx := context.Background()
+	_, ok := Get(ctx)
+	require.False(t, ok)
+	childCtx, span := StartChild(ctx, "child")
+	require.Equal(t, ctx, childCtx)
+	require.Nil(t, span)
+
+	// spans are not recorded without exporter
+	rootCtx, span := Start(ctx, "root")
+	require.Nil(t, span)
+	root, ok := Get(rootCtx)
+	require.True(t, ok)
+	require.Equal(t, FlagSampled, root.Flags)
+
+	childCtx, _ = StartChild(rootCtx, "child")
+	child, ok := Get(childCtx)
+	require.True(t, ok)
+	require.Equal(t, root.TraceID, child.TraceID)
+	require.NotEqual(t, root.SpanID, child.SpanID)
+
+	otherCtx, _ := Start(ctx, "other")
+	other, ok := Get(otherCtx)
+	require.True(t, ok)
+	require.NotEqual(t, root.TraceID, other.TraceID)
+}
+
+func TestInjectExtract(t *testing.T) {
+	header := http.Header{}
+	Inject(context.Background(), header)
+	require.Empty(t, header)
+	require.Equal(t, context.Background(), Extract(context.Background(), header))
+
+	header.Set(HeaderTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	header.Add(HeaderTracestate, "vendor1=a")
+	header.Add(HeaderTracestate, "vendor2=b")
+	ctx := Extract(context.Background(), header)
+	sc, ok := Get(ctx)
+	require.True(t, ok)
+	require.Equal(t, "vendor1=a,vendor2=b", sc.State)
+
+	ctx, _ = StartChild(ctx, "child")
+	child, _ := Get(ctx)
+	outgoing := http.Header{}
+	Inject(ctx, outgoing)
+	require.Equal(t, child.Traceparent(), outgoing.Get(HeaderTraceparent))
+	require.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+child.SpanID.String()+"-00", outgoing.Get(HeaderTraceparent))
+	require.Equal(t, "vendor1=a,vendor2=b", outgoing.Get(HeaderTracestate))
+}